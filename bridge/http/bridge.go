@@ -0,0 +1,296 @@
+// Package http provides a bridge exposing the states and actions of a plugin to other
+// programs on the same machine, over HTTP and a WebSocket.
+//
+//	GET  /states        the value of every state, by id
+//	GET  /states/{id}   the value of a single state
+//	POST /actions/{id}  handles the action, with an optional body of {"data": {"id": "value"}}
+//	GET  /stream        a WebSocket sending each state as it changes, starting with them all
+//
+// Every request must carry the bridges token, either as a bearer token in the
+// Authorization header or, for clients unable to set headers, a token query parameter.
+package http
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/marcokaiser/touchportal-golang-sdk/client"
+	"github.com/marcokaiser/touchportal-golang-sdk/plugin"
+	"golang.org/x/net/websocket"
+)
+
+// DefaultStreamBuffer is how many state changes may be waiting to be sent to a stream
+// client before it is considered too slow and disconnected.
+const DefaultStreamBuffer = 64
+
+// Option allows the configuration of a Bridge when calling New
+type Option func(b *Bridge)
+
+// WithLogger sets the Logger the bridge reports its activity to
+func WithLogger(l client.Logger) Option {
+	return func(b *Bridge) {
+		b.logger = l
+	}
+}
+
+// WithStreamBuffer sets how many state changes may be waiting to be sent to a stream
+// client. It defaults to DefaultStreamBuffer.
+func WithStreamBuffer(n int) Option {
+	return func(b *Bridge) {
+		b.streamBuffer = n
+	}
+}
+
+// Bridge serves the states and actions of a plugin over HTTP
+type Bridge struct {
+	plugin       *plugin.Plugin
+	token        string
+	logger       client.Logger
+	streamBuffer int
+	mux          *http.ServeMux
+}
+
+// State is the value of a single state, as served by the bridge
+type State struct {
+	ID    string `json:"id"`
+	Value string `json:"value"`
+}
+
+type actionRequest struct {
+	Data map[string]string `json:"data"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// New creates a Bridge for the plugin, requiring token of every request
+func New(p *plugin.Plugin, token string, opts ...Option) (*Bridge, error) {
+	if token == "" {
+		return nil, errors.New("a token is required to protect the bridge")
+	}
+
+	b := &Bridge{
+		plugin:       p,
+		token:        token,
+		logger:       client.DefaultLogger(),
+		streamBuffer: DefaultStreamBuffer,
+		mux:          http.NewServeMux(),
+	}
+
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	b.mux.HandleFunc("/states", b.handleStates)
+	b.mux.HandleFunc("/states/", b.handleState)
+	b.mux.HandleFunc("/actions/", b.handleAction)
+	b.mux.Handle("/stream", websocket.Server{
+		// any origin is accepted as the token, which a page from elsewhere does not
+		// know, is what protects the stream
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler:   b.handleStream,
+	})
+
+	return b, nil
+}
+
+// ServeHTTP serves a request to the bridge, once its token has been checked
+func (b *Bridge) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !b.authorised(r) {
+		writeJSON(w, http.StatusUnauthorized, errorResponse{Error: "missing or invalid token"})
+		return
+	}
+
+	b.mux.ServeHTTP(w, r)
+}
+
+// ListenAndServe serves the bridge on addr until the context is done. The address must be
+// a loopback address, such as "127.0.0.1:8080", as the bridge is only meant for programs
+// on the same machine.
+func (b *Bridge) ListenAndServe(ctx context.Context, addr string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid bridge address %q: %w", addr, err)
+	}
+
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return fmt.Errorf("bridge address %q is not a loopback address", addr)
+	}
+
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	return b.Serve(ctx, l)
+}
+
+// Serve serves the bridge on the listener until the context is done
+func (b *Bridge) Serve(ctx context.Context, l net.Listener) error {
+	srv := &http.Server{
+		Handler:           b,
+		ReadHeaderTimeout: 5 * time.Second,
+		BaseContext: func(net.Listener) context.Context {
+			return ctx
+		},
+	}
+
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	b.logger.Info("serving plugin bridge", "pluginId", b.plugin.ID, "addr", l.Addr().String())
+
+	err := srv.Serve(l)
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+
+	return err
+}
+
+func (b *Bridge) authorised(r *http.Request) bool {
+	token := r.URL.Query().Get("token")
+	if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		token = bearer
+	}
+
+	return subtle.ConstantTimeCompare([]byte(token), []byte(b.token)) == 1
+}
+
+func (b *Bridge) handleStates(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "method not allowed"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, b.plugin.States())
+}
+
+func (b *Bridge) handleState(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "method not allowed"})
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/states/")
+
+	value, ok := b.plugin.State(id)
+	if !ok {
+		writeJSON(w, http.StatusNotFound, errorResponse{Error: fmt.Sprintf("no value has been sent for state %q", id)})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, State{ID: id, Value: value})
+}
+
+func (b *Bridge) handleAction(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "method not allowed"})
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/actions/")
+
+	var req actionRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Error: fmt.Sprintf("invalid action body: %v", err)})
+			return
+		}
+	}
+
+	err := b.plugin.DispatchAction(id, req.Data)
+	if errors.Is(err, plugin.ErrNoRoute) {
+		writeJSON(w, http.StatusNotFound, errorResponse{Error: fmt.Sprintf("no handler for action %q", id)})
+		return
+	}
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: err.Error()})
+		return
+	}
+
+	b.logger.Debug("dispatched action from bridge", "pluginId", b.plugin.ID, "actionId", id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (b *Bridge) handleStream(ws *websocket.Conn) {
+	defer ws.Close()
+
+	updates := make(chan State, b.streamBuffer)
+	overflow := make(chan struct{})
+	overflowOnce := sync.Once{}
+
+	// subscribing before taking the snapshot means no change can be missed, at worst a
+	// change is sent twice
+	unsub := b.plugin.OnStateUpdate(func(id, value string) {
+		select {
+		case updates <- State{ID: id, Value: value}:
+		default:
+			overflowOnce.Do(func() {
+				close(overflow)
+			})
+		}
+	})
+	defer unsub()
+
+	states := b.plugin.States()
+	ids := make([]string, 0, len(states))
+	for id := range states {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		if err := websocket.JSON.Send(ws, State{ID: id, Value: states[id]}); err != nil {
+			return
+		}
+	}
+
+	// the client is not expected to send anything, reading only notices it going away
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+
+		var discard []byte
+		for websocket.Message.Receive(ws, &discard) == nil {
+		}
+	}()
+
+	for {
+		select {
+		case state := <-updates:
+			if err := websocket.JSON.Send(ws, state); err != nil {
+				return
+			}
+		case <-overflow:
+			b.logger.Warn("bridge stream client too slow, disconnecting", "pluginId", b.plugin.ID)
+			return
+		case <-closed:
+			return
+		case <-ws.Request().Context().Done():
+			return
+		}
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,135 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/marcokaiser/touchportal-golang-sdk/client"
+	"github.com/marcokaiser/touchportal-golang-sdk/plugin"
+	"github.com/marcokaiser/touchportal-golang-sdk/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/websocket"
+)
+
+const token = "secret"
+
+func newServer(t *testing.T, p *plugin.Plugin) *httptest.Server {
+	b, err := New(p, token)
+	assert.NoError(t, err)
+
+	srv := httptest.NewServer(b)
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func request(t *testing.T, method, url, auth, body string) (int, string) {
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	assert.NoError(t, err)
+
+	if auth != "" {
+		req.Header.Set("Authorization", "Bearer "+auth)
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer res.Body.Close()
+
+	b, _ := io.ReadAll(res.Body)
+
+	return res.StatusCode, strings.TrimSpace(string(b))
+}
+
+func TestNew_requiresToken(t *testing.T) {
+	t.Parallel()
+
+	_, err := New(nil, "")
+	assert.Error(t, err)
+}
+
+func TestBridge(t *testing.T) {
+	t.Parallel()
+
+	p := plugintest.New(t, "test").Plugin
+	srv := newServer(t, p)
+
+	var handled client.ActionMessage
+	p.OnAction(func(event client.ActionMessage) {
+		handled = event
+	}, "gsdk_toggle")
+
+	assert.NoError(t, p.UpdateState("gsdk_power", "on"))
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		auth       string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "no token", method: http.MethodGet, path: "/states", wantStatus: http.StatusUnauthorized, wantBody: `{"error":"missing or invalid token"}`},
+		{name: "wrong token", method: http.MethodGet, path: "/states", auth: "guess", wantStatus: http.StatusUnauthorized, wantBody: `{"error":"missing or invalid token"}`},
+		{name: "token query", method: http.MethodGet, path: "/states?token=" + token, wantStatus: http.StatusOK, wantBody: `{"gsdk_power":"on"}`},
+		{name: "states", method: http.MethodGet, path: "/states", auth: token, wantStatus: http.StatusOK, wantBody: `{"gsdk_power":"on"}`},
+		{name: "state", method: http.MethodGet, path: "/states/gsdk_power", auth: token, wantStatus: http.StatusOK, wantBody: `{"id":"gsdk_power","value":"on"}`},
+		{name: "unknown state", method: http.MethodGet, path: "/states/gsdk_volume", auth: token, wantStatus: http.StatusNotFound, wantBody: `{"error":"no value has been sent for state \"gsdk_volume\""}`},
+		{name: "action", method: http.MethodPost, path: "/actions/gsdk_toggle", auth: token, body: `{"data":{"device":"lamp"}}`, wantStatus: http.StatusNoContent},
+		{name: "unknown action", method: http.MethodPost, path: "/actions/gsdk_explode", auth: token, wantStatus: http.StatusNotFound, wantBody: `{"error":"no handler for action \"gsdk_explode\""}`},
+		{name: "invalid action body", method: http.MethodPost, path: "/actions/gsdk_toggle", auth: token, body: `{`, wantStatus: http.StatusBadRequest, wantBody: `{"error":"invalid action body: unexpected EOF"}`},
+		{name: "action by get", method: http.MethodGet, path: "/actions/gsdk_toggle", auth: token, wantStatus: http.StatusMethodNotAllowed, wantBody: `{"error":"method not allowed"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := request(t, tt.method, srv.URL+tt.path, tt.auth, tt.body)
+			assert.Equal(t, tt.wantStatus, status)
+			assert.Equal(t, tt.wantBody, body)
+		})
+	}
+
+	assert.Equal(t, "gsdk_toggle", handled.ActionID)
+	assert.JSONEq(t, `[{"id":"device","value":"lamp"}]`, string(handled.Data))
+}
+
+func TestBridge_stream(t *testing.T) {
+	t.Parallel()
+
+	p := plugintest.New(t, "test").Plugin
+	srv := newServer(t, p)
+
+	assert.NoError(t, p.UpdateState("gsdk_power", "on"))
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/stream?token=" + token
+	ws, err := websocket.Dial(url, "", srv.URL)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer ws.Close()
+	assert.NoError(t, ws.SetDeadline(time.Now().Add(5*time.Second)))
+
+	var state State
+	assert.NoError(t, websocket.JSON.Receive(ws, &state))
+	assert.Equal(t, State{ID: "gsdk_power", Value: "on"}, state)
+
+	assert.NoError(t, p.UpdateState("gsdk_power", "off"))
+
+	assert.NoError(t, websocket.JSON.Receive(ws, &state))
+	assert.Equal(t, State{ID: "gsdk_power", Value: "off"}, state)
+}
+
+func TestBridge_ListenAndServe_loopbackOnly(t *testing.T) {
+	t.Parallel()
+
+	b, err := New(nil, token)
+	assert.NoError(t, err)
+
+	err = b.ListenAndServe(context.Background(), "0.0.0.0:0")
+	assert.ErrorContains(t, err, "not a loopback address")
+}
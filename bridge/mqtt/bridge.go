@@ -0,0 +1,396 @@
+// Package mqtt provides a bridge between a plugin and an MQTT broker, driven by a
+// declarative mapping. Messages on subscribed topics update states, whilst actions and
+// connector changes publish to topics.
+//
+//	cfg := mqtt.Config{
+//	    States: []mqtt.StateMapping{
+//	        {Topic: "home/+/temperature", State: "gsdk_temperature", Template: "{{.Payload}}°C"},
+//	    },
+//	    Actions: []mqtt.ActionMapping{
+//	        {ActionID: "gsdk_light", Topic: "home/{{.Data.room}}/light/set", Payload: "{{.Data.state}}"},
+//	    },
+//	}
+//
+// Topics and payloads are text/template templates, see StateData, ActionData and
+// ConnectorData for what each is given. Values put into a topic have the characters with
+// a meaning in topics escaped, so they cannot reach other topics than the mapping names.
+package mqtt
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/marcokaiser/touchportal-golang-sdk/client"
+	"github.com/marcokaiser/touchportal-golang-sdk/plugin"
+)
+
+// Config is the mapping between topics and the states, actions and connectors of a plugin
+type Config struct {
+	States     []StateMapping     `json:"states"`
+	Actions    []ActionMapping    `json:"actions"`
+	Connectors []ConnectorMapping `json:"connectors"`
+}
+
+// StateMapping updates a state with each message received on a topic. The topic may use
+// the + and # wildcards. Without a template the payload is used as the value.
+type StateMapping struct {
+	Topic    string `json:"topic"`
+	State    string `json:"state"`
+	Template string `json:"template,omitempty"`
+	// IgnoreRetained skips retained messages, such as those sent when subscribing,
+	// leaving the state alone until the value next changes.
+	IgnoreRetained bool `json:"ignoreRetained,omitempty"`
+}
+
+// ActionMapping publishes to a topic each time an action is handled. The action id may be
+// a pattern, as with plugin.OnAction. Without a payload template the action data is
+// published as a JSON object.
+type ActionMapping struct {
+	ActionID string `json:"actionId"`
+	Topic    string `json:"topic"`
+	Payload  string `json:"payload,omitempty"`
+	Retain   bool   `json:"retain,omitempty"`
+}
+
+// ConnectorMapping publishes to a topic each time a connector changes. The connector id
+// may be a pattern, as with plugin.OnConnectorChange. Without a payload template the
+// value is published.
+type ConnectorMapping struct {
+	ConnectorID string `json:"connectorId"`
+	Topic       string `json:"topic"`
+	Payload     string `json:"payload,omitempty"`
+	Retain      bool   `json:"retain,omitempty"`
+}
+
+// StateData is given to the template of a StateMapping
+type StateData struct {
+	Topic   string
+	Payload string
+	// Value is the payload decoded as JSON, or nil if it is not JSON
+	Value interface{}
+}
+
+// ActionData is given to the topic and payload templates of an ActionMapping
+type ActionData struct {
+	ActionID string
+	Data     map[string]string
+}
+
+// ConnectorData is given to the topic and payload templates of a ConnectorMapping
+type ConnectorData struct {
+	ConnectorID string
+	Value       int
+	Data        map[string]string
+}
+
+// topicEscaper escapes the topic level separator and wildcards, along with the escape
+// character itself, in the values given to a topic template. A value such as "a/#" would
+// otherwise publish to topics at other levels than the mapping names.
+var topicEscaper = strings.NewReplacer("%", "%25", "/", "%2F", "+", "%2B", "#", "%23", "\x00", "%00")
+
+// escaped returns a copy of the data with its values escaped for use in a topic
+func (d ActionData) escaped() ActionData {
+	return ActionData{ActionID: topicEscaper.Replace(d.ActionID), Data: escapeValues(d.Data)}
+}
+
+// escaped returns a copy of the data with its values escaped for use in a topic
+func (d ConnectorData) escaped() ConnectorData {
+	return ConnectorData{ConnectorID: topicEscaper.Replace(d.ConnectorID), Value: d.Value, Data: escapeValues(d.Data)}
+}
+
+func escapeValues(values map[string]string) map[string]string {
+	escaped := make(map[string]string, len(values))
+	for k, v := range values {
+		escaped[k] = topicEscaper.Replace(v)
+	}
+
+	return escaped
+}
+
+// Option allows the configuration of a Bridge when calling New
+type Option func(b *Bridge)
+
+// WithLogger sets the Logger the bridge reports its activity to
+func WithLogger(l client.Logger) Option {
+	return func(b *Bridge) {
+		b.logger = l
+	}
+}
+
+// Bridge connects the states, actions and connectors of a plugin to an MQTT broker
+type Bridge struct {
+	plugin *plugin.Plugin
+	broker Broker
+	logger client.Logger
+
+	states     []stateRoute
+	actions    []publishRoute
+	connectors []publishRoute
+
+	mu      sync.Mutex
+	running bool
+	stops   []func()
+}
+
+type stateRoute struct {
+	StateMapping
+	template *template.Template
+}
+
+type publishRoute struct {
+	id      string
+	retain  bool
+	topic   *template.Template
+	payload *template.Template
+}
+
+// New creates a Bridge between the plugin and broker, checking every template of the
+// configuration. Nothing is subscribed to or published until Start is called.
+func New(p *plugin.Plugin, broker Broker, cfg Config, opts ...Option) (*Bridge, error) {
+	b := &Bridge{
+		plugin: p,
+		broker: broker,
+		logger: client.DefaultLogger(),
+	}
+
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	var errs []error
+	for i, m := range cfg.States {
+		if m.Topic == "" || m.State == "" {
+			errs = append(errs, fmt.Errorf("state mapping %d: a topic and state are required", i))
+			continue
+		}
+
+		tmpl, err := parseTemplate(m.Template)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("state mapping %d: %w", i, err))
+			continue
+		}
+
+		b.states = append(b.states, stateRoute{StateMapping: m, template: tmpl})
+	}
+
+	for i, m := range cfg.Actions {
+		r, err := newPublishRoute(m.ActionID, m.Topic, m.Payload, m.Retain)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("action mapping %d: %w", i, err))
+			continue
+		}
+
+		b.actions = append(b.actions, r)
+	}
+
+	for i, m := range cfg.Connectors {
+		r, err := newPublishRoute(m.ConnectorID, m.Topic, m.Payload, m.Retain)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("connector mapping %d: %w", i, err))
+			continue
+		}
+
+		b.connectors = append(b.connectors, r)
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// Start subscribes to the topics of the state mappings and begins publishing actions and
+// connector changes. If a subscription fails anything already started is stopped again.
+func (b *Bridge) Start() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.running {
+		return errors.New("bridge already started")
+	}
+
+	// brokers, paho included, keep a single subscription for each filter, so mappings
+	// sharing a filter share its subscription
+	filters, routes := b.routesByFilter()
+	for _, filter := range filters {
+		filter, routes := filter, routes[filter]
+
+		unsub, err := b.broker.Subscribe(filter, func(topic string, payload []byte, retained bool) {
+			for _, r := range routes {
+				b.handleMessage(r, topic, payload, retained)
+			}
+		})
+		if err != nil {
+			b.stopLocked()
+			return fmt.Errorf("unable to subscribe to %q: %w", filter, err)
+		}
+
+		b.stops = append(b.stops, func() {
+			if err := unsub(); err != nil {
+				b.logger.Warn("unable to unsubscribe from topic", "pluginId", b.plugin.ID, "topic", filter, "error", err)
+			}
+		})
+	}
+
+	for _, r := range b.actions {
+		r := r
+
+		b.stops = append(b.stops, b.plugin.OnAction(func(event client.ActionMessage) {
+			data, err := event.Values()
+			if err != nil {
+				b.logger.Warn("unable to read action data", "pluginId", b.plugin.ID, "actionId", event.ActionID, "error", err)
+				return
+			}
+
+			d := ActionData{ActionID: event.ActionID, Data: data}
+			b.publish(r, d.escaped(), d, data)
+		}, r.id))
+	}
+
+	for _, r := range b.connectors {
+		r := r
+
+		b.stops = append(b.stops, b.plugin.OnConnectorChange(func(event client.ConnectorChangeMessage) {
+			data, err := event.Values()
+			if err != nil {
+				b.logger.Warn("unable to read connector data", "pluginId", b.plugin.ID, "connectorId", event.ConnectorID, "error", err)
+				return
+			}
+
+			d := ConnectorData{ConnectorID: event.ConnectorID, Value: event.Value, Data: data}
+			b.publish(r, d.escaped(), d, event.Value)
+		}, r.id))
+	}
+
+	b.running = true
+
+	return nil
+}
+
+// Stop unsubscribes from every topic and stops publishing. The bridge may be started again.
+func (b *Bridge) Stop() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.stopLocked()
+}
+
+func (b *Bridge) stopLocked() {
+	for _, stop := range b.stops {
+		stop()
+	}
+
+	b.stops = nil
+	b.running = false
+}
+
+// routesByFilter groups the state routes by their topic filter, returning the filters in
+// the order they were first mapped
+func (b *Bridge) routesByFilter() ([]string, map[string][]stateRoute) {
+	var filters []string
+	routes := make(map[string][]stateRoute)
+
+	for _, r := range b.states {
+		if _, ok := routes[r.Topic]; !ok {
+			filters = append(filters, r.Topic)
+		}
+		routes[r.Topic] = append(routes[r.Topic], r)
+	}
+
+	return filters, routes
+}
+
+func (b *Bridge) handleMessage(r stateRoute, topic string, payload []byte, retained bool) {
+	if retained && r.IgnoreRetained {
+		return
+	}
+
+	value := string(payload)
+	if r.template != nil {
+		data := StateData{Topic: topic, Payload: value}
+		if err := json.Unmarshal(payload, &data.Value); err != nil {
+			data.Value = nil
+		}
+
+		v, err := execute(r.template, data)
+		if err != nil {
+			b.logger.Warn("unable to render state", "pluginId", b.plugin.ID, "stateId", r.State, "topic", topic, "error", err)
+			return
+		}
+		value = v
+	}
+
+	if err := b.plugin.UpdateState(r.State, value); err != nil {
+		b.logger.Warn("unable to update state from topic", "pluginId", b.plugin.ID, "stateId", r.State, "topic", topic, "error", err)
+	}
+}
+
+// publish renders the topic of the route with topicData, its values escaped, and the
+// payload with data, publishing fallback as JSON when the route has no payload template.
+func (b *Bridge) publish(r publishRoute, topicData, data interface{}, fallback interface{}) {
+	topic, err := execute(r.topic, topicData)
+	if err != nil {
+		b.logger.Warn("unable to render topic", "pluginId", b.plugin.ID, "id", r.id, "error", err)
+		return
+	}
+
+	var payload []byte
+	if r.payload != nil {
+		p, err := execute(r.payload, data)
+		if err != nil {
+			b.logger.Warn("unable to render payload", "pluginId", b.plugin.ID, "id", r.id, "error", err)
+			return
+		}
+		payload = []byte(p)
+	} else if payload, err = json.Marshal(fallback); err != nil {
+		b.logger.Warn("unable to marshal payload", "pluginId", b.plugin.ID, "id", r.id, "error", err)
+		return
+	}
+
+	if err := b.broker.Publish(topic, payload, r.retain); err != nil {
+		b.logger.Warn("unable to publish to topic", "pluginId", b.plugin.ID, "id", r.id, "topic", topic, "error", err)
+	}
+}
+
+func newPublishRoute(id, topic, payload string, retain bool) (publishRoute, error) {
+	if id == "" || topic == "" {
+		return publishRoute{}, errors.New("an id and topic are required")
+	}
+
+	r := publishRoute{id: id, retain: retain}
+
+	var err error
+	if r.topic, err = parseTemplate(topic); err != nil {
+		return publishRoute{}, fmt.Errorf("topic: %w", err)
+	}
+
+	if r.payload, err = parseTemplate(payload); err != nil {
+		return publishRoute{}, fmt.Errorf("payload: %w", err)
+	}
+
+	return r, nil
+}
+
+// parseTemplate parses text as a template, returning nil for an empty text
+func parseTemplate(text string) (*template.Template, error) {
+	if text == "" {
+		return nil, nil
+	}
+
+	return template.New("").Option("missingkey=zero").Parse(text)
+}
+
+func execute(t *template.Template, data interface{}) (string, error) {
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
@@ -0,0 +1,219 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/marcokaiser/touchportal-golang-sdk/client"
+	"github.com/marcokaiser/touchportal-golang-sdk/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+)
+
+type published struct {
+	topic    string
+	payload  string
+	retained bool
+}
+
+// record subscribes to every topic of the broker, returning what has been published
+func record(t *testing.T, b Broker) func() []published {
+	var (
+		mu   sync.Mutex
+		msgs []published
+	)
+
+	_, err := b.Subscribe("#", func(topic string, payload []byte, retained bool) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		msgs = append(msgs, published{topic: topic, payload: string(payload), retained: retained})
+	})
+	assert.NoError(t, err)
+
+	return func() []published {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return append([]published(nil), msgs...)
+	}
+}
+
+func TestNew_invalidConfig(t *testing.T) {
+	t.Parallel()
+
+	_, err := New(nil, NewMemoryBroker(), Config{
+		States:  []StateMapping{{Topic: "a", State: "s", Template: "{{.Payload"}},
+		Actions: []ActionMapping{{ActionID: "gsdk_a"}},
+	})
+	assert.ErrorContains(t, err, "state mapping 0")
+	assert.ErrorContains(t, err, "action mapping 0")
+}
+
+func TestBridge_states(t *testing.T) {
+	t.Parallel()
+
+	p := plugintest.New(t, "test").Plugin
+	broker := NewMemoryBroker()
+
+	assert.NoError(t, broker.Publish("home/hall/temperature", []byte("18"), true))
+	assert.NoError(t, broker.Publish("home/hall/humidity", []byte(`{"value": 40}`), true))
+
+	b, err := New(p, broker, Config{
+		States: []StateMapping{
+			{Topic: "home/+/temperature", State: "gsdk_temperature", Template: "{{.Payload}}°C"},
+			{Topic: "home/+/humidity", State: "gsdk_humidity", Template: "{{.Value.value}}%", IgnoreRetained: true},
+			{Topic: "home/door", State: "gsdk_door"},
+		},
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, b.Start())
+
+	// retained messages are delivered on subscribing, unless ignored
+	value, _ := p.State("gsdk_temperature")
+	assert.Equal(t, "18°C", value)
+	_, ok := p.State("gsdk_humidity")
+	assert.False(t, ok)
+
+	assert.NoError(t, broker.Publish("home/hall/humidity", []byte(`{"value": 45}`), false))
+	assert.NoError(t, broker.Publish("home/door", []byte("open"), false))
+
+	value, _ = p.State("gsdk_humidity")
+	assert.Equal(t, "45%", value)
+	value, _ = p.State("gsdk_door")
+	assert.Equal(t, "open", value)
+
+	b.Stop()
+
+	assert.NoError(t, broker.Publish("home/door", []byte("closed"), false))
+	value, _ = p.State("gsdk_door")
+	assert.Equal(t, "open", value, "state updated after stopping")
+}
+
+func TestBridge_sharedFilter(t *testing.T) {
+	t.Parallel()
+
+	p := plugintest.New(t, "test").Plugin
+	broker := NewMemoryBroker()
+
+	b, err := New(p, broker, Config{
+		States: []StateMapping{
+			{Topic: "home/+/temperature", State: "gsdk_temperature"},
+			{Topic: "home/+/temperature", State: "gsdk_temperature_label", Template: "{{.Topic}}: {{.Payload}}"},
+		},
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, b.Start())
+
+	assert.NoError(t, broker.Publish("home/hall/temperature", []byte("18"), false))
+
+	value, _ := p.State("gsdk_temperature")
+	assert.Equal(t, "18", value)
+	value, _ = p.State("gsdk_temperature_label")
+	assert.Equal(t, "home/hall/temperature: 18", value)
+
+	// stopping removes the one subscription both mappings share
+	b.Stop()
+
+	assert.NoError(t, broker.Publish("home/hall/temperature", []byte("19"), false))
+	value, _ = p.State("gsdk_temperature")
+	assert.Equal(t, "18", value)
+}
+
+func TestBridge_actionsAndConnectors(t *testing.T) {
+	t.Parallel()
+
+	tp := plugintest.New(t, "test")
+	p, c := tp.Plugin, tp.Client
+	broker := NewMemoryBroker()
+	msgs := record(t, broker)
+
+	b, err := New(p, broker, Config{
+		Actions: []ActionMapping{
+			{ActionID: "gsdk_light", Topic: "home/{{.Data.room}}/light/set", Payload: "{{.Data.state}}", Retain: true},
+			{ActionID: "gsdk_scene_*", Topic: "home/scene"},
+		},
+		Connectors: []ConnectorMapping{
+			{ConnectorID: "gsdk_volume", Topic: "home/volume"},
+		},
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, b.Start())
+	defer b.Stop()
+
+	assert.NoError(t, p.DispatchAction("gsdk_light", map[string]string{"room": "hall", "state": "on"}))
+	assert.NoError(t, p.DispatchAction("gsdk_scene_evening", map[string]string{"level": "2"}))
+
+	c.Dispatch(client.MessageTypeConnectorChange, client.ConnectorChangeMessage{
+		Message:     client.Message{Type: client.MessageTypeConnectorChange},
+		PluginID:    "test",
+		ConnectorID: "gsdk_volume",
+		Value:       42,
+		Data:        json.RawMessage(`[]`),
+	})
+
+	assert.Equal(t, []published{
+		{topic: "home/hall/light/set", payload: "on"},
+		{topic: "home/scene", payload: `{"level":"2"}`},
+		{topic: "home/volume", payload: "42"},
+	}, msgs())
+
+	// the retained message is delivered to later subscribers
+	late := record(t, broker)
+	assert.Equal(t, []published{{topic: "home/hall/light/set", payload: "on", retained: true}}, late())
+}
+
+func TestBridge_topicValues(t *testing.T) {
+	t.Parallel()
+
+	p := plugintest.New(t, "test").Plugin
+	broker := NewMemoryBroker()
+	msgs := record(t, broker)
+
+	b, err := New(p, broker, Config{
+		Actions: []ActionMapping{
+			{ActionID: "gsdk_light", Topic: "home/{{.Data.room}}/light/set", Payload: "{{.Data.room}}"},
+		},
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, b.Start())
+	defer b.Stop()
+
+	// values cannot add levels or wildcards to the topic, though the payload is left alone
+	assert.NoError(t, p.DispatchAction("gsdk_light", map[string]string{"room": "hall/+/#"}))
+	assert.NoError(t, p.DispatchAction("gsdk_light", map[string]string{"room": "50%"}))
+
+	assert.Equal(t, []published{
+		{topic: "home/hall%2F%2B%2F%23/light/set", payload: "hall/+/#"},
+		{topic: "home/50%25/light/set", payload: "50%"},
+	}, msgs())
+}
+
+func TestTopicMatches(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		filter string
+		topic  string
+		want   bool
+	}{
+		{filter: "a/b", topic: "a/b", want: true},
+		{filter: "a/b", topic: "a/c", want: false},
+		{filter: "a/+", topic: "a/b", want: true},
+		{filter: "a/+", topic: "a/b/c", want: false},
+		{filter: "a/+/c", topic: "a/b/c", want: true},
+		{filter: "a/#", topic: "a/b/c", want: true},
+		{filter: "a/#", topic: "a", want: true},
+		{filter: "#", topic: "a/b", want: true},
+		{filter: "a/b/c", topic: "a/b", want: false},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.filter+" "+tt.topic, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, topicMatches(tt.filter, tt.topic))
+		})
+	}
+}
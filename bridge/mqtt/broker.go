@@ -0,0 +1,162 @@
+package mqtt
+
+import (
+	"strings"
+	"sync"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+)
+
+// Handler is called with each message received on a subscribed topic
+type Handler func(topic string, payload []byte, retained bool)
+
+// Broker is the part of an MQTT connection the bridge makes use of. NewPahoBroker adapts
+// a paho client to it, whilst MemoryBroker provides one in process, for tests.
+type Broker interface {
+	Publish(topic string, payload []byte, retained bool) error
+	Subscribe(topic string, handler Handler) (unsubscribe func() error, err error)
+}
+
+type pahoBroker struct {
+	client paho.Client
+	qos    byte
+}
+
+// NewPahoBroker adapts a connected paho client to a Broker, publishing and subscribing
+// with the given quality of service.
+func NewPahoBroker(c paho.Client, qos byte) Broker {
+	return &pahoBroker{client: c, qos: qos}
+}
+
+func (b *pahoBroker) Publish(topic string, payload []byte, retained bool) error {
+	t := b.client.Publish(topic, b.qos, retained, payload)
+	t.Wait()
+
+	return t.Error()
+}
+
+func (b *pahoBroker) Subscribe(topic string, handler Handler) (func() error, error) {
+	t := b.client.Subscribe(topic, b.qos, func(_ paho.Client, m paho.Message) {
+		handler(m.Topic(), m.Payload(), m.Retained())
+	})
+	t.Wait()
+	if err := t.Error(); err != nil {
+		return nil, err
+	}
+
+	return func() error {
+		t := b.client.Unsubscribe(topic)
+		t.Wait()
+
+		return t.Error()
+	}, nil
+}
+
+// MemoryBroker is a Broker kept entirely in process. It supports the + and # topic
+// wildcards and retained messages, which are delivered to new subscribers as MQTT would.
+// Like the connection of a single MQTT client it keeps one subscription for each filter,
+// subscribing to a filter again replacing its handler.
+type MemoryBroker struct {
+	mu            sync.RWMutex
+	retained      map[string][]byte
+	subscriptions []*subscription
+}
+
+type subscription struct {
+	filter  string
+	handler Handler
+}
+
+// NewMemoryBroker creates an empty MemoryBroker
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{retained: make(map[string][]byte)}
+}
+
+// Publish delivers the payload to every subscription matching the topic. A retained
+// message is also kept for later subscribers, an empty one removing what was kept.
+func (b *MemoryBroker) Publish(topic string, payload []byte, retained bool) error {
+	b.mu.Lock()
+	if retained {
+		if len(payload) == 0 {
+			delete(b.retained, topic)
+		} else {
+			b.retained[topic] = payload
+		}
+	}
+	subs := b.subscriptions
+	b.mu.Unlock()
+
+	// like a broker, messages are delivered to existing subscribers as not retained
+	for _, s := range subs {
+		if topicMatches(s.filter, topic) {
+			s.handler(topic, payload, false)
+		}
+	}
+
+	return nil
+}
+
+// Subscribe calls handler with every message published to a topic matching the filter,
+// starting with any retained ones. It replaces any handler already subscribed to the
+// filter, and unsubscribing removes the filter whichever handler it has.
+func (b *MemoryBroker) Subscribe(filter string, handler Handler) (func() error, error) {
+	b.mu.Lock()
+	b.subscriptions = append(withoutFilter(b.subscriptions, filter), &subscription{filter: filter, handler: handler})
+
+	retained := make(map[string][]byte)
+	for topic, payload := range b.retained {
+		if topicMatches(filter, topic) {
+			retained[topic] = payload
+		}
+	}
+	b.mu.Unlock()
+
+	for topic, payload := range retained {
+		handler(topic, payload, true)
+	}
+
+	return func() error {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		b.subscriptions = withoutFilter(b.subscriptions, filter)
+
+		return nil
+	}, nil
+}
+
+// withoutFilter returns a copy of subs without the subscription to filter. A new slice
+// is built as a publish in progress may still be ranging over the old one.
+func withoutFilter(subs []*subscription, filter string) []*subscription {
+	remaining := make([]*subscription, 0, len(subs)+1)
+	for _, existing := range subs {
+		if existing.filter != filter {
+			remaining = append(remaining, existing)
+		}
+	}
+
+	return remaining
+}
+
+// topicMatches reports whether the topic matches the filter, which may use the single
+// level + and multi level # wildcards.
+func topicMatches(filter, topic string) bool {
+	filters := strings.Split(filter, "/")
+	levels := strings.Split(topic, "/")
+
+	for i, f := range filters {
+		if f == "#" {
+			return true
+		}
+
+		if i >= len(levels) {
+			return false
+		}
+
+		if f != "+" && f != levels[i] {
+			return false
+		}
+	}
+
+	return len(filters) == len(levels)
+}
@@ -0,0 +1,65 @@
+package client
+
+import "context"
+
+// HandlerRegistrar is anything message handlers can be added to, such as a Client
+type HandlerRegistrar interface {
+	AddMessageHandler(msgType ClientMessageType, handler func(e interface{})) Unsubscribe
+}
+
+// Expectation is an interest in the next message of a type that matches a predicate. It
+// is registered straight away so that a message sent in response to a request cannot be
+// missed, even when it arrives before Wait is called.
+type Expectation struct {
+	matched chan interface{}
+	unsub   Unsubscribe
+}
+
+// Expect registers an Expectation for the next message of msgType for which predicate
+// returns true. A nil predicate matches any message of the type.
+func Expect(r HandlerRegistrar, msgType ClientMessageType, predicate func(e interface{}) bool) *Expectation {
+	e := &Expectation{matched: make(chan interface{}, 1)}
+
+	e.unsub = r.AddMessageHandler(msgType, func(event interface{}) {
+		if predicate != nil && !predicate(event) {
+			return
+		}
+
+		select {
+		case e.matched <- event:
+		default:
+		}
+	})
+
+	return e
+}
+
+// Wait blocks until the expected message arrives, returning it, or until the context is
+// done, returning its error. Either way the expectation is removed.
+func (e *Expectation) Wait(ctx context.Context) (interface{}, error) {
+	defer e.Cancel()
+
+	select {
+	case event := <-e.matched:
+		return event, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Cancel removes the expectation without waiting for it
+func (e *Expectation) Cancel() {
+	if e.unsub != nil {
+		e.unsub()
+	}
+}
+
+// Await waits for the next message of msgType for which predicate returns true, or
+// until the context is done.
+//
+//	e, err := c.Await(ctx, client.MessageTypeNotificationOptionClicked, func(e interface{}) bool {
+//	    return e.(client.NotificationOptionClickedMessage).NotificationID == "gsdk_update"
+//	})
+func (c *Client) Await(ctx context.Context, msgType ClientMessageType, predicate func(e interface{}) bool) (interface{}, error) {
+	return Expect(c, msgType, predicate).Wait(ctx)
+}
@@ -0,0 +1,66 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpect(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		predicate func(e interface{}) bool
+		events    []interface{}
+		want      interface{}
+		wantErr   error
+	}{
+		{
+			name:   "nil predicate matches first message",
+			events: []interface{}{ListChangeMessage{ListID: "a"}, ListChangeMessage{ListID: "b"}},
+			want:   ListChangeMessage{ListID: "a"},
+		},
+		{
+			name: "predicate skips unmatched messages",
+			predicate: func(e interface{}) bool {
+				return e.(ListChangeMessage).ListID == "b"
+			},
+			events: []interface{}{ListChangeMessage{ListID: "a"}, ListChangeMessage{ListID: "b"}},
+			want:   ListChangeMessage{ListID: "b"},
+		},
+		{
+			name: "no match times out",
+			predicate: func(e interface{}) bool {
+				return false
+			},
+			events:  []interface{}{ListChangeMessage{ListID: "a"}},
+			wantErr: context.DeadlineExceeded,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := NewClient()
+			e := Expect(c, MessageTypeListChange, tt.predicate)
+
+			// messages arriving before Wait is called must not be missed
+			for _, event := range tt.events {
+				c.Dispatch(MessageTypeListChange, event)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+
+			got, err := e.Wait(ctx)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.want, got)
+			assert.Empty(t, c.handlers[MessageTypeListChange], "expectation not removed")
+		})
+	}
+}
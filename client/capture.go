@@ -0,0 +1,57 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// CaptureEntry is a single message recorded from TouchPortal. A capture is stored as
+// JSON lines, one entry per message, in the order in which they were received.
+type CaptureEntry struct {
+	Time    time.Time       `json:"time"`
+	Message json.RawMessage `json:"message"`
+}
+
+// CaptureWriter writes incoming TouchPortal messages out in the capture format.
+type CaptureWriter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewCaptureWriter creates a CaptureWriter that writes to the given io.Writer
+func NewCaptureWriter(w io.Writer) *CaptureWriter {
+	return &CaptureWriter{enc: json.NewEncoder(w)}
+}
+
+// Write records the raw message, as read from the socket, against the current time.
+func (cw *CaptureWriter) Write(msg []byte) error {
+	cw.mu.Lock()
+	defer cw.mu.Unlock()
+
+	return cw.enc.Encode(CaptureEntry{
+		Time:    time.Now(),
+		Message: json.RawMessage(bytes.TrimSpace(msg)),
+	})
+}
+
+// CaptureReader reads back messages previously written by a CaptureWriter.
+type CaptureReader struct {
+	dec *json.Decoder
+}
+
+// NewCaptureReader creates a CaptureReader that reads from the given io.Reader
+func NewCaptureReader(r io.Reader) *CaptureReader {
+	return &CaptureReader{dec: json.NewDecoder(r)}
+}
+
+// Next returns the next entry of the capture. Once the capture is exhausted io.EOF
+// is returned.
+func (cr *CaptureReader) Next() (CaptureEntry, error) {
+	var e CaptureEntry
+	err := cr.dec.Decode(&e)
+
+	return e, err
+}
@@ -1,52 +1,118 @@
 package client
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
 	"net"
+	"reflect"
+	"strconv"
 	"sync"
+	"time"
 
+	"github.com/marcokaiser/touchportal-golang-sdk/internal/sliceutil"
 	"golang.org/x/net/context"
 )
 
+// ErrClosed is returned when sending a message using a client that has been closed
+var ErrClosed = errors.New("client is closed")
+
 const (
 	tpPort = 12136
 	tpHost = "127.0.0.1"
 )
 
+// transport is the means by which a Client exchanges raw messages with TouchPortal
+type transport interface {
+	GetMessage() ([]byte, error)
+	SendMessage(m []byte) error
+	Close()
+}
+
 type Client struct {
-	socket      *Socket
+	socket      transport
+	recorder    *CaptureWriter
+	logger      Logger
+	metrics     Metrics
+	trace       bool
 	incoming    chan []byte
 	fetchStop   chan bool
 	processStop chan bool
 	ready       chan bool
-
-	handlers   map[ClientMessageType][]func(e interface{})
-	processors map[ClientMessageType]func(msg json.RawMessage) (interface{}, error)
+	closed      chan bool
+	stopOnce    sync.Once
+	closeOnce   sync.Once
+	sendMu      sync.Mutex
+	panicPolicy PanicPolicy
+
+	handlersMu    sync.RWMutex
+	handlers      map[ClientMessageType][]*messageHandler
+	anyHandlers   []*messageHandler
+	errorHandlers []*errorHandler
+	middleware    []Middleware
+	processors    map[ClientMessageType]func(msg json.RawMessage) (interface{}, error)
+	types         map[ClientMessageType]reflect.Type
+	unchecked     map[ClientMessageType]bool
 }
 
-func NewClient() *Client {
+func NewClient(opts ...Option) *Client {
 	c := &Client{
 		incoming:    make(chan []byte, 5),
 		fetchStop:   make(chan bool),
 		processStop: make(chan bool),
 		ready:       make(chan bool),
-		handlers:    make(map[ClientMessageType][]func(event interface{})),
+		closed:      make(chan bool),
+		handlers:    make(map[ClientMessageType][]*messageHandler),
 		processors:  make(map[ClientMessageType]func(msg json.RawMessage) (interface{}, error)),
+		types:       make(map[ClientMessageType]reflect.Type),
+		unchecked:   make(map[ClientMessageType]bool),
+		logger:      DefaultLogger(),
+		metrics:     noopMetrics{},
 	}
 
 	c.registerDefaultMessageProcessors()
 
+	for _, opt := range opts {
+		opt(c)
+	}
+
 	return c
 }
 
-func (c *Client) AddMessageHandler(msgType ClientMessageType, handler func(e interface{})) {
-	if _, contains := c.handlers[msgType]; !contains {
-		c.handlers[msgType] = []func(event interface{}){}
-	}
+// AddMessageHandler adds a handler that is called for every message of the given type.
+// The returned Unsubscribe removes the handler again.
+func (c *Client) AddMessageHandler(msgType ClientMessageType, handler func(e interface{})) Unsubscribe {
+	h := &messageHandler{fn: handler}
 
-	c.handlers[msgType] = append(c.handlers[msgType], handler)
+	c.handlersMu.Lock()
+	c.handlers[msgType] = append(c.handlers[msgType], h)
+	c.handlersMu.Unlock()
+
+	return newUnsubscribe(func() {
+		c.removeMessageHandler(msgType, h)
+	})
+}
+
+// OnAny adds a handler that is called with every incoming message as it was received,
+// before it is processed, whatever its type. It is intended for experimenting with
+// messages the SDK does not yet support. The returned Unsubscribe removes the handler again.
+func (c *Client) OnAny(handler func(msg RawMessage)) Unsubscribe {
+	h := &messageHandler{fn: func(e interface{}) {
+		handler(e.(RawMessage))
+	}}
+
+	c.handlersMu.Lock()
+	c.anyHandlers = append(c.anyHandlers, h)
+	c.handlersMu.Unlock()
+
+	return newUnsubscribe(func() {
+		c.handlersMu.Lock()
+		defer c.handlersMu.Unlock()
+
+		h.disabled.Store(true)
+		c.anyHandlers = sliceutil.Without(c.anyHandlers, h)
+	})
 }
 
 func (c *Client) Ready() <-chan bool {
@@ -54,13 +120,18 @@ func (c *Client) Ready() <-chan bool {
 }
 
 func (c *Client) Run(ctx context.Context) {
-	conn, err := net.Dial("tcp", fmt.Sprintf("%s:%d", tpHost, tpPort))
-	if err != nil {
-		log.Fatalf("unable to connect to touchportal: %v. exiting...", err)
-	}
-	defer conn.Close()
+	if c.socket == nil {
+		conn, err := net.Dial("tcp", net.JoinHostPort(tpHost, strconv.Itoa(tpPort)))
+		if err != nil {
+			fatal(c.logger, "unable to connect to touchportal. exiting...", "error", err)
+		}
 
-	c.socket = NewSocket(conn)
+		socket := NewSocket(conn)
+		socket.logger = c.logger
+		socket.metrics = c.metrics
+		c.socket = socket
+	}
+	defer c.socket.Close()
 
 	// by closing the ready channel we're telling any observers that enough of
 	// this client has started that they can begin using it
@@ -76,34 +147,121 @@ func (c *Client) Run(ctx context.Context) {
 	// Watch for the context cancellation so we can ask our
 	// goroutines to exit
 	go func() {
-		<-ctx.Done()
-		c.Close()
+		select {
+		case <-ctx.Done():
+			c.Close()
+		case <-c.closed:
+		}
 	}()
 
 	// wait for goroutines to exit
 	wg.Wait()
+
+	select {
+	case <-c.fetchStop:
+		// having been asked to stop receiving messages, the connection is kept open
+		// for sending until the client is closed
+		<-c.closed
+	default:
+		c.Close()
+	}
+
+	// let any message being sent finish before the connection is closed
+	c.Flush()
 }
 
+// StopReceiving asks the client to stop fetching and processing incoming messages whilst
+// keeping the connection open, so messages can still be sent as a part of a graceful
+// shutdown. Close finishes the shutdown. It is safe to call more than once.
+func (c *Client) StopReceiving() {
+	c.stopOnce.Do(func() {
+		close(c.fetchStop)
+		close(c.processStop)
+	})
+}
+
+// Close asks the client to stop processing messages and close its connection. It is
+// safe to call more than once.
 func (c *Client) Close() {
-	close(c.fetchStop)
-	close(c.processStop)
-	close(c.incoming)
+	c.StopReceiving()
+	c.closeOnce.Do(func() {
+		close(c.closed)
+	})
+}
+
+// Flush waits for any message that is being sent to finish sending
+func (c *Client) Flush() {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
 }
 
+// Dispatch passes the event through any middleware and then to every handler of the
+// given message type. A panic in a handler is recovered and dealt with according to the
+// clients PanicPolicy.
 func (c *Client) Dispatch(mType ClientMessageType, event interface{}) {
-	for _, handler := range c.handlers[mType] {
-		handler(event)
+	c.handlersMu.RLock()
+	handlers := c.handlers[mType]
+	middleware := c.middleware
+	c.handlersMu.RUnlock()
+
+	chain(middleware, func(mType ClientMessageType, event interface{}) {
+		for _, handler := range handlers {
+			if handler.disabled.Load() {
+				continue
+			}
+
+			c.invoke(mType, handler, event)
+		}
+	})(mType, event)
+}
+
+// dispatchRaw passes the message, as it was received, to the handlers added with OnAny
+func (c *Client) dispatchRaw(mType ClientMessageType, msg RawMessage) {
+	c.handlersMu.RLock()
+	handlers := c.anyHandlers
+	c.handlersMu.RUnlock()
+
+	for _, handler := range handlers {
+		if handler.disabled.Load() {
+			continue
+		}
+
+		c.invoke(mType, handler, msg)
 	}
 }
 
 // SendMessage will send a JSON serialised version of the passed interface{}
 // to TouchPortal, returning an error if it was unable to complete the task
 func (c *Client) SendMessage(m interface{}) error {
-	return c.socket.SendMessage(toJSON(m))
+	msg, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("unable to marshal message %T: %w", m, err)
+	}
+
+	if c.trace {
+		c.logger.Debug("sending frame", "type", typeOf(m), "bytes", len(msg), "frame", string(msg))
+	}
+
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+
+	select {
+	case <-c.closed:
+		return fmt.Errorf("unable to send message %T: %w", m, ErrClosed)
+	default:
+	}
+
+	err = c.socket.SendMessage(msg)
+	if err == nil {
+		c.metrics.MessageSent(typeOf(m))
+	}
+
+	return err
 }
 
 func (c *Client) fetchIncomingMessage(wg *sync.WaitGroup) {
 	defer wg.Done()
+	defer close(c.incoming)
 
 	for {
 		select {
@@ -111,12 +269,36 @@ func (c *Client) fetchIncomingMessage(wg *sync.WaitGroup) {
 			return
 		default:
 			msg, err := c.socket.GetMessage()
+			if errors.Is(err, errSourceExhausted) {
+				// a finite source of messages, such as a replay, has run dry. closing
+				// incoming lets everything already fetched be processed before exiting
+				c.logger.Info("no more messages available to process. stopping...", "reason", err)
+				return
+			}
+
 			if err != nil {
-				log.Fatalf("the connection has been lost with touchportal. exiting...")
+				fatal(c.logger, "the connection has been lost with touchportal. exiting...", "error", err)
+			}
+
+			if msg == nil {
+				continue
+			}
+
+			if c.trace {
+				c.logger.Debug("received frame", "bytes", len(msg), "frame", string(bytes.TrimSpace(msg)))
 			}
 
-			if msg != nil {
-				c.incoming <- msg
+			if c.recorder != nil {
+				if err := c.recorder.Write(msg); err != nil {
+					c.logger.Warn("unable to record message", "error", err)
+				}
+			}
+
+			select {
+			case c.incoming <- msg:
+				c.metrics.QueueDepth(len(c.incoming))
+			case <-c.fetchStop:
+				return
 			}
 		}
 	}
@@ -129,43 +311,77 @@ func (c *Client) processIncomingMessages(wg *sync.WaitGroup) {
 		select {
 		case <-c.processStop:
 			return
-		case msg := <-c.incoming:
+		case msg, ok := <-c.incoming:
+			if !ok {
+				return
+			}
+
+			c.metrics.QueueDepth(len(c.incoming))
 			c.processMessage(msg)
 		}
 	}
 }
 
 func (c *Client) processMessage(msg json.RawMessage) {
-	var m Message
+	var envelope struct {
+		Type string `json:"type"`
+	}
 
-	err := json.Unmarshal(msg, &m)
+	err := json.Unmarshal(msg, &envelope)
 	if err != nil {
-		log.Printf("unable to marshall message and discern type: %v\n", err)
+		c.logger.Warn("unable to unmarshal message and discern type", "bytes", len(msg), "error", err)
+		c.metrics.MessageReceived("unknown")
+		c.metrics.UnhandledMessage("unknown")
+
 		return
 	}
 
-	mType := ClientMessageType(m.Type)
+	mType, err := ClientMessageTypeString(envelope.Type)
+	if err != nil {
+		// most likely a type added by a newer version of TouchPortal, which is passed
+		// on as an UnknownMessage
+		c.logger.Debug("received message of unknown type", "type", envelope.Type, "bytes", len(msg))
+		mType = MessageTypeUnknown
+	}
+	c.metrics.MessageReceived(mType.String())
+
+	c.dispatchRaw(mType, RawMessage{TypeName: envelope.Type, Raw: msg})
 
 	processor, ok := c.processors[mType]
 	if !ok {
-		log.Printf("type of message \"%s\" not currently handled\n", mType)
+		c.logger.Warn("type of message not currently handled", "type", mType, "bytes", len(msg))
+		c.metrics.UnhandledMessage(mType.String())
+
 		return
 	}
 
 	pm, err := processor(msg)
 	if err != nil {
-		log.Printf("unable to marshall message into type %s: %v\n", mType, err)
+		c.logger.Warn("unable to unmarshal message into type", "type", mType, "bytes", len(msg), "error", err)
+		c.metrics.ProcessorError(mType.String())
+
+		return
+	}
+
+	if want, ok := c.types[mType]; ok && !c.unchecked[mType] && reflect.TypeOf(pm) != want {
+		c.logger.Warn("message processor produced the wrong type", "type", mType, "want", want, "got", fmt.Sprintf("%T", pm))
+		c.metrics.ProcessorError(mType.String())
+
 		return
 	}
 
+	c.logger.Debug("dispatching message", "type", mType, "bytes", len(msg))
+
+	start := time.Now()
 	c.Dispatch(mType, pm)
+	c.metrics.HandlerDuration(mType.String(), time.Since(start))
 }
 
-func toJSON(msg interface{}) []byte {
-	msgBytes, err := json.Marshal(msg)
-	if err != nil {
-		log.Fatalf("unable to marshal message struct to string %v", msg)
+// typeOf returns the type of an outgoing message, or "unknown" if it does not embed Message
+func typeOf(m interface{}) string {
+	if t, ok := m.(interface{ messageType() ClientMessageType }); ok {
+		return t.messageType().String()
 	}
 
-	return msgBytes
+	return "unknown"
 }
@@ -2,46 +2,175 @@ package client
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
 )
 
+// ErrProcessorType is returned when a message processor produces a different type to the
+// one the handlers of its message type expect.
+var ErrProcessorType = errors.New("message processor produces the wrong type")
+
+// builtinMessage is a message type the client processes by default, along with the type
+// its messages are processed into
+type builtinMessage struct {
+	msgType   ClientMessageType
+	typ       reflect.Type
+	processor func(msg json.RawMessage) (interface{}, error)
+}
+
+func builtin[T TypedMessage](msgType ClientMessageType) builtinMessage {
+	return builtinMessage{
+		msgType: msgType,
+		typ:     typeFor[T](),
+		processor: func(msg json.RawMessage) (interface{}, error) {
+			return decode[T](msg)
+		},
+	}
+}
+
+// builtinMessages lists the incoming message types. Where several share a type, such as
+// the action, down and up messages, the first is the one Handle registers for.
+var builtinMessages = []builtinMessage{
+	builtin[ActionMessage](MessageTypeAction),
+	builtin[BroadcastMessage](MessageTypeBroadcast),
+	builtin[ClosePluginMessage](MessageTypeClosePlugin),
+	builtin[ConnectorChangeMessage](MessageTypeConnectorChange),
+	builtin[ActionMessage](MessageTypeDown),
+	builtin[InfoMessage](MessageTypeInfo),
+	builtin[ListChangeMessage](MessageTypeListChange),
+	builtin[NotificationOptionClickedMessage](MessageTypeNotificationOptionClicked),
+	builtin[SettingsMessage](MessageTypeSettings),
+	{
+		msgType:   MessageTypeUnknown,
+		typ:       typeFor[UnknownMessage](),
+		processor: unknownMessageProcessor,
+	},
+	builtin[ActionMessage](MessageTypeUp),
+}
+
 // SetMessageProcessor lets you use your own handling of incoming message types.
 // Your provided processor function should turn the provided raw JSON into the interface you're
 // expecting - probably a struct of some sort.
+//
+// The type it produces is not checked, its messages are passed to every handler of the
+// message type as they are. Handlers registered with Handle or HandleType, including those
+// of the plugin, are skipped for messages of any other type than they expect. Use
+// SetProcessor to have the type checked when the processor is set instead.
 func (c *Client) SetMessageProcessor(msgType ClientMessageType, processor func(msg json.RawMessage) (interface{}, error)) {
 	c.processors[msgType] = processor
+	c.unchecked[msgType] = true
+}
+
+// SetProcessor lets you use your own handling of incoming message types, as
+// SetMessageProcessor does, with the type the processor produces checked up front. It
+// returns ErrProcessorType when the handlers of the message type expect another type.
+func SetProcessor[T TypedMessage](c *Client, msgType ClientMessageType, processor func(msg json.RawMessage) (T, error)) error {
+	if want, ok := c.types[msgType]; ok && typeFor[T]() != want {
+		return fmt.Errorf("%w: %s messages are handled as %s, not %s", ErrProcessorType, msgType, want, typeFor[T]())
+	}
+
+	c.processors[msgType] = func(msg json.RawMessage) (interface{}, error) {
+		return processor(msg)
+	}
+	delete(c.unchecked, msgType)
+
+	return nil
 }
 
 func (c *Client) registerDefaultMessageProcessors() {
-	c.SetMessageProcessor(MessageTypeAction, actionMessageProcessor)
-	c.SetMessageProcessor(MessageTypeClosePlugin, closePluginProcessor)
-	c.SetMessageProcessor(MessageTypeInfo, infoMessageProcessor)
-	c.SetMessageProcessor(MessageTypeSettings, settingsMessageProcessor)
+	for _, m := range builtinMessages {
+		c.types[m.msgType] = m.typ
+		c.processors[m.msgType] = m.processor
+	}
 }
 
-func actionMessageProcessor(msg json.RawMessage) (interface{}, error) {
-	var pm ActionMessage
-	err := json.Unmarshal(msg, &pm)
+// decode unmarshals the message into T, keeping any fields T does not know about in the
+// Extra of its Message.
+func decode[T TypedMessage](msg json.RawMessage) (T, error) {
+	var pm T
+	if err := json.Unmarshal(msg, &pm); err != nil {
+		return pm, err
+	}
 
-	return pm, err
-}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(msg, &fields); err != nil {
+		return pm, err
+	}
+
+	known := knownFields(typeFor[T]())
+	for name := range fields {
+		// encoding/json matches names regardless of case, so the same is done here
+		if known[strings.ToLower(name)] {
+			delete(fields, name)
+		}
+	}
 
-func closePluginProcessor(msg json.RawMessage) (interface{}, error) {
-	var pm ClosePluginMessage
-	err := json.Unmarshal(msg, &pm)
+	if m, ok := interface{}(&pm).(interface {
+		setExtra(map[string]json.RawMessage)
+	}); ok && len(fields) > 0 {
+		m.setExtra(fields)
+	}
 
-	return pm, err
+	return pm, nil
 }
 
-func infoMessageProcessor(msg json.RawMessage) (interface{}, error) {
-	var pm InfoMessage
-	err := json.Unmarshal(msg, &pm)
+func unknownMessageProcessor(msg json.RawMessage) (interface{}, error) {
+	var envelope struct {
+		Type string `json:"type"`
+	}
+	err := json.Unmarshal(msg, &envelope)
 
-	return pm, err
+	return UnknownMessage{
+		Message:  Message{Type: MessageTypeUnknown},
+		TypeName: envelope.Type,
+		Raw:      msg,
+	}, err
 }
 
-func settingsMessageProcessor(msg json.RawMessage) (interface{}, error) {
-	var pm SettingsMessage
-	err := json.Unmarshal(msg, &pm)
+var knownFieldsCache sync.Map
+
+// knownFields returns the lower cased JSON names of the fields of a struct type, including
+// those of any embedded structs
+func knownFields(typ reflect.Type) map[string]bool {
+	if known, ok := knownFieldsCache.Load(typ); ok {
+		return known.(map[string]bool)
+	}
+
+	known := make(map[string]bool)
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			for embedded := range knownFields(field.Type) {
+				known[embedded] = true
+			}
+
+			continue
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		known[strings.ToLower(name)] = true
+	}
+
+	knownFieldsCache.Store(typ, known)
+
+	return known
+}
 
-	return pm, err
+func typeFor[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
 }
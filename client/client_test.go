@@ -0,0 +1,123 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_Dispatch_recoversPanics(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		policy     PanicPolicy
+		dispatches int
+		wantCalls  int
+		wantErrors int
+		wantClosed bool
+	}{
+		{
+			name:       "it keeps calling a panicking handler by default",
+			policy:     PanicContinue,
+			dispatches: 3,
+			wantCalls:  3,
+			wantErrors: 3,
+		},
+		{
+			name:       "it disables a handler after the configured number of panics",
+			policy:     PanicDisableAfter(2),
+			dispatches: 4,
+			wantCalls:  2,
+			wantErrors: 2,
+		},
+		{
+			name:       "it closes the client when asked to shutdown",
+			policy:     PanicShutdown,
+			dispatches: 1,
+			wantCalls:  1,
+			wantErrors: 1,
+			wantClosed: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var errs []error
+			c := NewClient(WithPanicPolicy(tt.policy), WithLogger(NewStdLogger(nil, LevelError+1)))
+			c.OnError(func(err error) {
+				errs = append(errs, err)
+			})
+
+			calls, others := 0, 0
+			c.AddMessageHandler(MessageTypeAction, func(e interface{}) {
+				calls++
+				panic(errors.New("boom"))
+			})
+			c.AddMessageHandler(MessageTypeAction, func(e interface{}) {
+				others++
+			})
+
+			for i := 0; i < tt.dispatches; i++ {
+				c.Dispatch(MessageTypeAction, ActionMessage{})
+			}
+
+			assert.Equal(t, tt.wantCalls, calls)
+			assert.Equal(t, tt.dispatches, others, "handlers after a panicking handler should still be called")
+			assert.Len(t, errs, tt.wantErrors)
+
+			var pe *PanicError
+			if assert.True(t, errors.As(errs[0], &pe)) {
+				assert.Equal(t, MessageTypeAction, pe.MessageType)
+				assert.NotEmpty(t, pe.Stack)
+				assert.EqualError(t, errors.Unwrap(pe), "boom")
+			}
+
+			select {
+			case <-c.fetchStop:
+				assert.True(t, tt.wantClosed, "client closed unexpectedly")
+			default:
+				assert.False(t, tt.wantClosed, "client should have been closed")
+			}
+		})
+	}
+}
+
+func TestClient_StopReceiving(t *testing.T) {
+	t.Parallel()
+
+	// a source that never runs dry, so only the client stops receiving
+	r, w := io.Pipe()
+	t.Cleanup(func() {
+		w.Close()
+	})
+
+	c := NewReplayClient(r, 0)
+
+	stopped := make(chan bool)
+	go func() {
+		c.Run(context.Background())
+		close(stopped)
+	}()
+	<-c.Ready()
+
+	c.StopReceiving()
+	assert.NoError(t, c.SendMessage(NewPairMessage("test")), "messages should be sent until the client is closed")
+
+	c.Close()
+	assert.ErrorIs(t, c.SendMessage(NewStateUpdateMessage("state", "on")), ErrClosed)
+
+	w.Close()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("client did not stop after being closed")
+	}
+}
@@ -0,0 +1,48 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// DataValue is a single data field of an action or connector, as sent by TouchPortal
+type DataValue struct {
+	ID    string          `json:"id"`
+	Value json.RawMessage `json:"value"`
+}
+
+// Values returns the data fields of the action by id
+func (m ActionMessage) Values() (map[string]string, error) {
+	return dataValues(m.Data)
+}
+
+// Values returns the data fields of the connector by id
+func (m ConnectorChangeMessage) Values() (map[string]string, error) {
+	return dataValues(m.Data)
+}
+
+// dataValues turns the data sent with an action or connector into a map of values by id.
+// Values are strings, but any other JSON value is kept as its JSON text.
+func dataValues(raw json.RawMessage) (map[string]string, error) {
+	values := make(map[string]string)
+	if len(raw) == 0 || string(raw) == "null" {
+		return values, nil
+	}
+
+	var data []DataValue
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal data: %w", err)
+	}
+
+	for _, d := range data {
+		var s string
+		if err := json.Unmarshal(d.Value, &s); err == nil {
+			values[d.ID] = s
+			continue
+		}
+
+		values[d.ID] = string(d.Value)
+	}
+
+	return values, nil
+}
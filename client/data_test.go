@@ -0,0 +1,39 @@
+package client
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActionMessage_Values(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		data    string
+		want    map[string]string
+		wantErr bool
+	}{
+		{name: "no data", data: "", want: map[string]string{}},
+		{name: "null data", data: "null", want: map[string]string{}},
+		{
+			name: "string and other values",
+			data: `[{"id":"room","value":"hall"},{"id":"level","value":2}]`,
+			want: map[string]string{"room": "hall", "level": "2"},
+		},
+		{name: "invalid data", data: `{"id":"room"}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ActionMessage{Data: json.RawMessage(tt.data)}.Values()
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
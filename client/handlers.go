@@ -0,0 +1,48 @@
+package client
+
+import "fmt"
+
+// TypedMessage is satisfied by the message types, all of which embed Message. It allows
+// handlers and processors to be registered for a message type without type assertions.
+type TypedMessage interface {
+	messageType() ClientMessageType
+}
+
+// Handle registers a handler for the message type that is processed into T, deriving the
+// type from the handlers argument. The returned Unsubscribe removes the handler again.
+//
+//	client.Handle(c, func(e client.InfoMessage) {
+//	    // e is already an InfoMessage
+//	})
+//
+// The down and up messages share ActionMessage with the action message, for which Handle
+// registers. Use HandleType to handle them. Handle panics if T is not the type of any
+// incoming message.
+func Handle[T TypedMessage](r HandlerRegistrar, handler func(event T)) Unsubscribe {
+	for _, m := range builtinMessages {
+		if m.typ == typeFor[T]() {
+			return HandleType(r, m.msgType, handler)
+		}
+	}
+
+	panic(fmt.Sprintf("%s is not the type of any incoming message", typeFor[T]()))
+}
+
+// HandleType registers a handler for the given message type, as Handle does, for when T
+// is shared by several message types. It panics if messages of the type are not
+// processed into T.
+func HandleType[T TypedMessage](r HandlerRegistrar, msgType ClientMessageType, handler func(event T)) Unsubscribe {
+	for _, m := range builtinMessages {
+		if m.msgType == msgType && m.typ != typeFor[T]() {
+			panic(fmt.Sprintf("%s messages are handled as %s, not %s", msgType, m.typ, typeFor[T]()))
+		}
+	}
+
+	return r.AddMessageHandler(msgType, func(e interface{}) {
+		// processors set with SetMessageProcessor, or events dispatched by hand, may be of
+		// some other type
+		if event, ok := e.(T); ok {
+			handler(event)
+		}
+	})
+}
@@ -0,0 +1,119 @@
+package client
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandle(t *testing.T) {
+	t.Parallel()
+
+	c := NewClient()
+
+	var got []InfoMessage
+	unsub := Handle(c, func(e InfoMessage) {
+		got = append(got, e)
+	})
+
+	c.processMessage(json.RawMessage(`{"type":"info","tpVersionString":"3.1"}`))
+	c.Dispatch(MessageTypeInfo, "not an info message")
+	unsub()
+	c.processMessage(json.RawMessage(`{"type":"info","tpVersionString":"3.2"}`))
+
+	assert.Equal(t, []InfoMessage{{Message: Message{Type: MessageTypeInfo}, Version: "3.1"}}, got)
+}
+
+func TestHandle_sharedType(t *testing.T) {
+	t.Parallel()
+
+	c := NewClient()
+
+	var actions, downs int
+	Handle(c, func(e ActionMessage) {
+		actions++
+	})
+	HandleType(c, MessageTypeDown, func(e ActionMessage) {
+		downs++
+	})
+
+	c.processMessage(json.RawMessage(`{"type":"action"}`))
+	c.processMessage(json.RawMessage(`{"type":"down"}`))
+	c.processMessage(json.RawMessage(`{"type":"up"}`))
+
+	assert.Equal(t, 1, actions)
+	assert.Equal(t, 1, downs)
+}
+
+func TestHandleType_mismatch(t *testing.T) {
+	t.Parallel()
+
+	assert.Panics(t, func() {
+		HandleType(NewClient(), MessageTypeInfo, func(e ActionMessage) {})
+	})
+}
+
+func TestClient_SetMessageProcessor_unchecked(t *testing.T) {
+	t.Parallel()
+
+	c := NewClient()
+
+	c.SetMessageProcessor(MessageTypeInfo, func(msg json.RawMessage) (interface{}, error) {
+		return "custom", nil
+	})
+
+	var got interface{}
+	c.AddMessageHandler(MessageTypeInfo, func(e interface{}) {
+		got = e
+	})
+	Handle(c, func(e InfoMessage) {
+		t.Error("typed handler called with a message of another type")
+	})
+
+	c.processMessage(json.RawMessage(`{"type":"info","tpVersionString":"3.1"}`))
+
+	assert.Equal(t, "custom", got, "message of a custom processor not passed to its handler")
+}
+
+func TestClient_processMessage_wrongType(t *testing.T) {
+	t.Parallel()
+
+	c := NewClient()
+	c.processors[MessageTypeInfo] = func(msg json.RawMessage) (interface{}, error) {
+		return "wrong", nil
+	}
+
+	called := false
+	c.AddMessageHandler(MessageTypeInfo, func(e interface{}) {
+		called = true
+	})
+
+	c.processMessage(json.RawMessage(`{"type":"info","tpVersionString":"3.1"}`))
+
+	assert.False(t, called, "handler called with wrongly typed message")
+}
+
+func TestSetProcessor(t *testing.T) {
+	t.Parallel()
+
+	c := NewClient()
+
+	err := SetProcessor(c, MessageTypeInfo, func(msg json.RawMessage) (ActionMessage, error) {
+		return ActionMessage{}, nil
+	})
+	assert.ErrorIs(t, err, ErrProcessorType)
+
+	err = SetProcessor(c, MessageTypeInfo, func(msg json.RawMessage) (InfoMessage, error) {
+		return InfoMessage{Version: "custom"}, nil
+	})
+	assert.NoError(t, err)
+
+	var got InfoMessage
+	Handle(c, func(e InfoMessage) {
+		got = e
+	})
+	c.processMessage(json.RawMessage(`{"type":"info"}`))
+
+	assert.Equal(t, "custom", got.Version)
+}
@@ -0,0 +1,133 @@
+package client
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Logger is the structured logger used throughout the SDK. Each message is followed by
+// alternating key and value pairs describing it. The method set matches *slog.Logger so
+// one can be passed wherever a Logger is accepted.
+type Logger interface {
+	Debug(msg string, args ...interface{})
+	Info(msg string, args ...interface{})
+	Warn(msg string, args ...interface{})
+	Error(msg string, args ...interface{})
+}
+
+// Level is the minimum severity of message written by the logger returned by NewStdLogger
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO"
+	case LevelWarn:
+		return "WARN"
+	case LevelError:
+		return "ERROR"
+	default:
+		return fmt.Sprintf("Level(%d)", int(l))
+	}
+}
+
+type stdLogger struct {
+	logger *log.Logger
+	level  Level
+}
+
+// NewStdLogger creates a Logger that writes key=value formatted lines to the given
+// *log.Logger, dropping any message less severe than level. If l is nil the output of
+// the standard log package is used.
+func NewStdLogger(l *log.Logger, level Level) Logger {
+	return &stdLogger{logger: l, level: level}
+}
+
+// DefaultLogger is the Logger used when none is provided. It writes messages of
+// LevelInfo and above through the standard log package.
+func DefaultLogger() Logger {
+	return NewStdLogger(nil, LevelInfo)
+}
+
+func (s *stdLogger) Debug(msg string, args ...interface{}) {
+	s.write(LevelDebug, msg, args)
+}
+
+func (s *stdLogger) Info(msg string, args ...interface{}) {
+	s.write(LevelInfo, msg, args)
+}
+
+func (s *stdLogger) Warn(msg string, args ...interface{}) {
+	s.write(LevelWarn, msg, args)
+}
+
+func (s *stdLogger) Error(msg string, args ...interface{}) {
+	s.write(LevelError, msg, args)
+}
+
+func (s *stdLogger) write(level Level, msg string, args []interface{}) {
+	if level < s.level {
+		return
+	}
+
+	b := &strings.Builder{}
+	b.WriteString(level.String())
+	b.WriteByte(' ')
+	b.WriteString(msg)
+
+	for i := 0; i < len(args); i += 2 {
+		key, value := "!BADKEY", args[i]
+		if i+1 < len(args) {
+			key, value = fmt.Sprint(args[i]), args[i+1]
+		}
+
+		b.WriteByte(' ')
+		b.WriteString(key)
+		b.WriteByte('=')
+		b.WriteString(formatValue(value))
+	}
+
+	if s.logger == nil {
+		log.Print(b.String())
+		return
+	}
+
+	s.logger.Print(b.String())
+}
+
+func formatValue(v interface{}) string {
+	var s string
+	switch x := v.(type) {
+	case []byte:
+		s = string(x)
+	case error:
+		s = x.Error()
+	default:
+		s = fmt.Sprint(x)
+	}
+
+	if s == "" || strings.ContainsAny(s, " \t\n\"=") {
+		return strconv.Quote(s)
+	}
+
+	return s
+}
+
+// fatal logs the message as an error before exiting the process, matching the behaviour
+// of log.Fatal for failures the client is unable to recover from.
+func fatal(l Logger, msg string, args ...interface{}) {
+	l.Error(msg, args...)
+	os.Exit(1)
+}
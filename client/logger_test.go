@@ -0,0 +1,28 @@
+package client
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStdLogger(t *testing.T) {
+	t.Parallel()
+
+	out := &bytes.Buffer{}
+	l := NewStdLogger(log.New(out, "", 0), LevelInfo)
+
+	l.Debug("not written", "type", MessageTypeAction)
+	l.Info("dispatching message", "type", MessageTypeAction, "bytes", 42)
+	l.Warn("unable to unmarshal", "error", errors.New("bad input"), "frame", []byte(`{"type":"x"}`))
+	l.Error("odd arguments", "dangling")
+
+	assert.Equal(t,
+		"INFO dispatching message type=action bytes=42\n"+
+			`WARN unable to unmarshal error="bad input" frame="{\"type\":\"x\"}"`+"\n"+
+			"ERROR odd arguments !BADKEY=dangling\n",
+		out.String())
+}
@@ -0,0 +1,37 @@
+package client
+
+import "time"
+
+// Metrics receives measurements of a clients activity so they can be exported to a
+// monitoring system. Message types are passed as their TouchPortal names. Implementations
+// must be safe for concurrent use.
+type Metrics interface {
+	// MessageReceived is called for every message read from TouchPortal
+	MessageReceived(msgType string)
+	// MessageSent is called for every message successfully sent to TouchPortal
+	MessageSent(msgType string)
+	// ProcessorError is called when a message could not be turned into its type
+	ProcessorError(msgType string)
+	// UnhandledMessage is called when no processor exists for a message type
+	UnhandledMessage(msgType string)
+	// HandlerPanic is called each time a handler panics whilst handling a message
+	HandlerPanic(msgType string)
+	// HandlerDuration reports how long the handlers for a single message took to run
+	HandlerDuration(msgType string, d time.Duration)
+	// QueueDepth reports the number of messages waiting to be processed
+	QueueDepth(depth int)
+	// ConnectionRetry is called each time the socket retries after a failed read
+	ConnectionRetry()
+}
+
+// noopMetrics is the Metrics used when none is provided
+type noopMetrics struct{}
+
+func (noopMetrics) MessageReceived(string)                {}
+func (noopMetrics) MessageSent(string)                    {}
+func (noopMetrics) ProcessorError(string)                 {}
+func (noopMetrics) UnhandledMessage(string)               {}
+func (noopMetrics) HandlerPanic(string)                   {}
+func (noopMetrics) HandlerDuration(string, time.Duration) {}
+func (noopMetrics) QueueDepth(int)                        {}
+func (noopMetrics) ConnectionRetry()                      {}
@@ -0,0 +1,27 @@
+package client
+
+// Handler handles a single message dispatched by the client
+type Handler func(msgType ClientMessageType, event interface{})
+
+// Middleware wraps the handling of messages. It is called once for every dispatched
+// message, whatever its type, and decides whether and how to call next, which passes
+// the message on to the next middleware and finally to the registered handlers.
+type Middleware func(next Handler) Handler
+
+// Use adds middleware to the client. Middleware is run in the order it is added, so the
+// first middleware added sees each message first.
+func (c *Client) Use(mw ...Middleware) {
+	c.handlersMu.Lock()
+	defer c.handlersMu.Unlock()
+
+	c.middleware = append(c.middleware, mw...)
+}
+
+// chain wraps the handler in all middleware added to the client
+func chain(middleware []Middleware, h Handler) Handler {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		h = middleware[i](h)
+	}
+
+	return h
+}
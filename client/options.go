@@ -0,0 +1,55 @@
+package client
+
+import "io"
+
+// Option allows the configuration of a Client when calling NewClient
+type Option func(c *Client)
+
+// WithRecorder records every message received from TouchPortal to the given io.Writer
+// using the capture format. The resulting capture can be fed back into a plugin using
+// NewReplayClient.
+func WithRecorder(w io.Writer) Option {
+	return func(c *Client) {
+		c.recorder = NewCaptureWriter(w)
+	}
+}
+
+// WithLogger sets the Logger the client reports its activity to. By default messages
+// of LevelInfo and above are written using the standard log package.
+func WithLogger(l Logger) Option {
+	return func(c *Client) {
+		c.logger = l
+	}
+}
+
+// WithTrace logs every raw frame sent to and received from TouchPortal at debug level.
+// This is noisy and intended for diagnosing problems with the communication itself.
+func WithTrace() Option {
+	return func(c *Client) {
+		c.trace = true
+	}
+}
+
+// WithMetrics reports measurements of the clients activity to the given Metrics. The
+// metrics package provides an implementation that can be exposed to Prometheus.
+func WithMetrics(m Metrics) Option {
+	return func(c *Client) {
+		c.metrics = m
+	}
+}
+
+// WithPanicPolicy decides what happens after a message handler panics. By default the
+// panic is reported and the handler continues to be called for later messages.
+func WithPanicPolicy(policy PanicPolicy) Option {
+	return func(c *Client) {
+		c.panicPolicy = policy
+	}
+}
+
+// WithErrorHandler adds a handler that is told about errors the client recovers from.
+// It is equivalent to calling OnError once the client is created.
+func WithErrorHandler(handler func(err error)) Option {
+	return func(c *Client) {
+		c.errorHandlers = append(c.errorHandlers, &errorHandler{fn: handler})
+	}
+}
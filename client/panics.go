@@ -0,0 +1,146 @@
+package client
+
+import (
+	"fmt"
+	"runtime/debug"
+	"sync/atomic"
+
+	"github.com/marcokaiser/touchportal-golang-sdk/internal/sliceutil"
+)
+
+type panicMode int
+
+const (
+	panicContinue panicMode = iota
+	panicDisable
+	panicShutdown
+)
+
+// PanicPolicy decides what happens after a message handler panics. Whatever the policy
+// the panic is recovered and reported to the error handlers as a *PanicError.
+type PanicPolicy struct {
+	mode  panicMode
+	limit int
+}
+
+var (
+	// PanicContinue keeps calling a handler regardless of how often it panics
+	PanicContinue = PanicPolicy{mode: panicContinue}
+	// PanicShutdown closes the client after the first panic, stopping the plugin
+	PanicShutdown = PanicPolicy{mode: panicShutdown}
+)
+
+// PanicDisableAfter stops calling a handler once it has panicked n times
+func PanicDisableAfter(n int) PanicPolicy {
+	return PanicPolicy{mode: panicDisable, limit: n}
+}
+
+// PanicError is reported to the error handlers when a message handler panics
+type PanicError struct {
+	MessageType ClientMessageType
+	Value       interface{}
+	Stack       []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("handler for %s message panicked: %v", e.MessageType, e.Value)
+}
+
+// Unwrap returns the value the handler panicked with when it is an error
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+
+	return err
+}
+
+// messageHandler is a handler added to the client along with its record of panics
+type messageHandler struct {
+	fn       func(e interface{})
+	panics   atomic.Int32
+	disabled atomic.Bool
+}
+
+type errorHandler struct {
+	fn func(err error)
+}
+
+// invoke calls the handler, recovering from and dealing with any panic according to
+// the clients PanicPolicy.
+func (c *Client) invoke(mType ClientMessageType, h *messageHandler, event interface{}) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+
+		c.metrics.HandlerPanic(mType.String())
+
+		err := &PanicError{MessageType: mType, Value: r, Stack: debug.Stack()}
+		c.logger.Error("message handler panicked", "type", mType, "panic", r, "stack", string(err.Stack))
+		c.reportError(err)
+
+		switch c.panicPolicy.mode {
+		case panicDisable:
+			if int(h.panics.Add(1)) >= c.panicPolicy.limit {
+				h.disabled.Store(true)
+				c.logger.Warn("message handler disabled after repeated panics", "type", mType, "panics", c.panicPolicy.limit)
+			}
+		case panicShutdown:
+			c.logger.Error("shutting down after message handler panic", "type", mType)
+			c.Close()
+		}
+	}()
+
+	h.fn(event)
+}
+
+// Guard wraps fn so that it is protected in the same way as a handler added with
+// AddMessageHandler; panics are recovered, reported and dealt with according to the
+// clients PanicPolicy. It allows handlers that are called indirectly, such as those of
+// the plugin action router, to be isolated from one another.
+func (c *Client) Guard(mType ClientMessageType, fn func(e interface{})) func(e interface{}) {
+	h := &messageHandler{fn: fn}
+
+	return func(e interface{}) {
+		if h.disabled.Load() {
+			return
+		}
+
+		c.invoke(mType, h, e)
+	}
+}
+
+// OnError adds a handler that is told about errors the client recovers from, such as
+// panics in message handlers. The returned Unsubscribe removes the handler again.
+func (c *Client) OnError(handler func(err error)) Unsubscribe {
+	h := &errorHandler{fn: handler}
+
+	c.handlersMu.Lock()
+	c.errorHandlers = append(c.errorHandlers, h)
+	c.handlersMu.Unlock()
+
+	return newUnsubscribe(func() {
+		c.handlersMu.Lock()
+		defer c.handlersMu.Unlock()
+
+		c.errorHandlers = sliceutil.Without(c.errorHandlers, h)
+	})
+}
+
+func (c *Client) reportError(err error) {
+	c.handlersMu.RLock()
+	handlers := c.errorHandlers
+	c.handlersMu.RUnlock()
+
+	for _, handler := range handlers {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					c.logger.Error("error handler panicked", "panic", r)
+				}
+			}()
+
+			handler.fn(err)
+		}()
+	}
+}
@@ -0,0 +1,107 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// errSourceExhausted is returned by a transport that has no further messages to offer
+var errSourceExhausted = errors.New("message source exhausted")
+
+// replayer is a transport that serves messages from a capture rather than from a
+// live TouchPortal socket. It mirrors TouchPortal in that nothing is sent until the
+// plugin has sent its first message, which in practice is the pairing request.
+type replayer struct {
+	capture *CaptureReader
+	speed   float64
+
+	started   chan bool
+	startOnce sync.Once
+
+	pending *CaptureEntry
+	due     time.Time
+
+	// each message is due its offset from the first, scaled by the speed, after the
+	// replay started, so time spent between messages does not push back those that follow
+	start time.Time
+	first time.Time
+}
+
+// NewReplayClient creates a client that, instead of connecting to TouchPortal, replays
+// a capture previously recorded using WithRecorder.
+//
+// The speed controls the pacing of the replay; 1 replays at the original speed, 2 at
+// twice the original speed and so on. A speed of 0 replays messages as fast as they
+// can be processed. Once the capture is exhausted the client shuts down as it would
+// when losing the connection to TouchPortal.
+func NewReplayClient(r io.Reader, speed float64, opts ...Option) *Client {
+	c := NewClient(opts...)
+	c.socket = &replayer{
+		capture: NewCaptureReader(r),
+		speed:   speed,
+		started: make(chan bool),
+	}
+
+	return c
+}
+
+// GetMessage returns the next message of the capture once it is due. Like Socket it
+// returns after a short while with no message so shutdowns can be handled.
+func (r *replayer) GetMessage() ([]byte, error) {
+	select {
+	case <-r.started:
+	case <-time.After(300 * time.Millisecond):
+		return nil, nil
+	}
+
+	if r.pending == nil {
+		e, err := r.capture.Next()
+		if errors.Is(err, io.EOF) {
+			return nil, errSourceExhausted
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("%w: unable to read capture: %v", errSourceExhausted, err)
+		}
+
+		if r.start.IsZero() {
+			r.start = time.Now()
+			r.first = e.Time
+		}
+
+		r.pending = &e
+		r.due = r.start
+		if r.speed > 0 {
+			r.due = r.start.Add(time.Duration(float64(e.Time.Sub(r.first)) / r.speed))
+		}
+	}
+
+	if wait := time.Until(r.due); wait > 0 {
+		if wait > 300*time.Millisecond {
+			wait = 300 * time.Millisecond
+		}
+		time.Sleep(wait)
+
+		return nil, nil
+	}
+
+	msg := r.pending.Message
+	r.pending = nil
+
+	return msg, nil
+}
+
+// SendMessage discards outgoing messages, the first of which starts the replay
+func (r *replayer) SendMessage(m []byte) error {
+	r.startOnce.Do(func() {
+		close(r.started)
+	})
+
+	return nil
+}
+
+// Close is a no-op as there is no underlying connection
+func (r *replayer) Close() {}
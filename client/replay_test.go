@@ -0,0 +1,124 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplayClient(t *testing.T) {
+	t.Parallel()
+
+	capture := &bytes.Buffer{}
+	cw := NewCaptureWriter(capture)
+
+	for _, msg := range []string{
+		`{"type":"info","sdkVersion":3}` + "\n",
+		`{"type":"action","pluginId":"test","actionId":"first"}` + "\n",
+		`{"type":"action","pluginId":"test","actionId":"second"}` + "\n",
+	} {
+		err := cw.Write([]byte(msg))
+		assert.Nil(t, err, "failed to write capture err: %v", err)
+	}
+
+	c := NewReplayClient(capture, 0)
+
+	var received []string
+	c.AddMessageHandler(MessageTypeInfo, func(e interface{}) {
+		received = append(received, "info")
+	})
+	c.AddMessageHandler(MessageTypeAction, func(e interface{}) {
+		received = append(received, e.(ActionMessage).ActionID)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	done := make(chan bool)
+	go func() {
+		c.Run(ctx)
+		close(done)
+	}()
+
+	<-c.Ready()
+	err := c.SendMessage(NewPairMessage("test"))
+	assert.Nil(t, err, "failed to send pair message err: %v", err)
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		t.Fatal("replay did not complete before timeout")
+	}
+
+	assert.Equal(t, []string{"info", "first", "second"}, received)
+}
+
+func TestReplayClient_speed(t *testing.T) {
+	t.Parallel()
+
+	start := time.Now()
+	capture := &bytes.Buffer{}
+	for i, offset := range []time.Duration{0, 400 * time.Millisecond} {
+		_, _ = capture.WriteString(`{"time":"` + start.Add(offset).Format(time.RFC3339Nano) +
+			`","message":{"type":"action","actionId":"` + string(rune('a'+i)) + `"}}` + "\n")
+	}
+
+	c := NewReplayClient(capture, 2)
+
+	var times []time.Time
+	c.AddMessageHandler(MessageTypeAction, func(e interface{}) {
+		times = append(times, time.Now())
+	})
+
+	go func() {
+		<-c.Ready()
+		_ = c.SendMessage(NewPairMessage("test"))
+	}()
+	c.Run(context.Background())
+
+	if assert.Len(t, times, 2) {
+		gap := times[1].Sub(times[0])
+		assert.InDelta(t, 200*time.Millisecond, gap, float64(100*time.Millisecond),
+			"replay at double speed should halve the gap between messages, got %s", gap)
+	}
+}
+
+func TestReplayClient_speed_slowReader(t *testing.T) {
+	t.Parallel()
+
+	start := time.Now()
+	capture := &bytes.Buffer{}
+	for i, offset := range []time.Duration{0, 100 * time.Millisecond, 200 * time.Millisecond} {
+		_, _ = capture.WriteString(`{"time":"` + start.Add(offset).Format(time.RFC3339Nano) +
+			`","message":{"type":"action","actionId":"` + string(rune('a'+i)) + `"}}` + "\n")
+	}
+
+	r := &replayer{capture: NewCaptureReader(capture), speed: 1, started: make(chan bool)}
+	_ = r.SendMessage(nil)
+
+	next := func() {
+		for {
+			msg, err := r.GetMessage()
+			assert.NoError(t, err)
+
+			if msg != nil {
+				return
+			}
+		}
+	}
+
+	began := time.Now()
+	next()
+
+	// the second message is read late, which must not delay the third
+	time.Sleep(150 * time.Millisecond)
+	next()
+	next()
+
+	elapsed := time.Since(began)
+	assert.InDelta(t, 200*time.Millisecond, elapsed, float64(40*time.Millisecond),
+		"the third message should be due 200ms into the replay, got %s", elapsed)
+}
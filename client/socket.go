@@ -2,24 +2,27 @@ package client
 
 import (
 	"bufio"
-	"log"
 	"net"
 	"time"
 )
 
 type Socket struct {
-	conn   net.Conn
-	reader *bufio.Reader
-	writer *bufio.Writer
+	conn    net.Conn
+	reader  *bufio.Reader
+	writer  *bufio.Writer
+	logger  Logger
+	metrics Metrics
 
 	retries int
 }
 
 func NewSocket(c net.Conn) *Socket {
 	return &Socket{
-		conn:   c,
-		reader: bufio.NewReader(c),
-		writer: bufio.NewWriter(c),
+		conn:    c,
+		reader:  bufio.NewReader(c),
+		writer:  bufio.NewWriter(c),
+		logger:  DefaultLogger(),
+		metrics: noopMetrics{},
 	}
 }
 
@@ -31,14 +34,15 @@ func (s *Socket) GetMessage() ([]byte, error) {
 
 	err := s.conn.SetReadDeadline(time.Now().Add(300 * time.Millisecond))
 	if err != nil {
-		log.Printf("error whilst setting connection read deadline: %v", err)
+		s.logger.Warn("error whilst setting connection read deadline", "error", err)
 	}
 
 	bytes, err := s.reader.ReadBytes('\n')
 	if err != nil {
 		if nerr, ok := err.(net.Error); !ok || !nerr.Timeout() {
-			log.Printf("error whilst reading from TCP socket: %+v\n", err)
+			s.logger.Warn("error whilst reading from TCP socket", "error", err, "retries", s.retries)
 
+			s.metrics.ConnectionRetry()
 			if s.retries++; s.retries > 10 {
 				return nil, err
 			}
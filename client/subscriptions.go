@@ -0,0 +1,98 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/marcokaiser/touchportal-golang-sdk/internal/sliceutil"
+)
+
+// Unsubscribe removes a previously registered handler. It is safe to call more than
+// once and from within the handler itself.
+type Unsubscribe func()
+
+// newUnsubscribe makes remove safe to call more than once
+func newUnsubscribe(remove func()) Unsubscribe {
+	once := sync.Once{}
+
+	return func() {
+		once.Do(remove)
+	}
+}
+
+// Once registers handler using register in such a way that it is called for, at most, a
+// single event, after which it is unsubscribed. It is the basis of the Once variants of
+// the handler registration functions.
+//
+//	client.Once(func(h func(client.InfoMessage)) client.Unsubscribe {
+//	    return p.OnInfo(h)
+//	}, handler)
+func Once[T any](register func(handler func(event T)) Unsubscribe, handler func(event T)) Unsubscribe {
+	var (
+		mu    sync.Mutex
+		fired atomic.Bool
+		unsub Unsubscribe
+	)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	unsub = register(func(event T) {
+		if !fired.CompareAndSwap(false, true) {
+			return
+		}
+
+		// wait for register to have returned so unsub is known
+		mu.Lock()
+		u := unsub
+		mu.Unlock()
+
+		u()
+		handler(event)
+	})
+
+	return unsub
+}
+
+// UnsubscribeOnDone removes all of the given handlers once the context is done. The
+// returned Unsubscribe removes them straight away instead, ending the goroutine that waits
+// on the context, so call it when the context may never be done.
+func UnsubscribeOnDone(ctx context.Context, unsubs ...Unsubscribe) Unsubscribe {
+	stopped := make(chan bool)
+	remove := newUnsubscribe(func() {
+		close(stopped)
+
+		for _, unsub := range unsubs {
+			unsub()
+		}
+	})
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			remove()
+		case <-stopped:
+		}
+	}()
+
+	return remove
+}
+
+// AddMessageHandlerOnce adds a handler that is only called for the next message of the
+// given type.
+func (c *Client) AddMessageHandlerOnce(msgType ClientMessageType, handler func(e interface{})) Unsubscribe {
+	return Once(func(h func(e interface{})) Unsubscribe {
+		return c.AddMessageHandler(msgType, h)
+	}, handler)
+}
+
+func (c *Client) removeMessageHandler(msgType ClientMessageType, h *messageHandler) {
+	c.handlersMu.Lock()
+	defer c.handlersMu.Unlock()
+
+	// stops a dispatch already in progress from calling the handler
+	h.disabled.Store(true)
+
+	c.handlers[msgType] = sliceutil.Without(c.handlers[msgType], h)
+}
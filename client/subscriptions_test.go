@@ -0,0 +1,90 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_AddMessageHandler_unsubscribe(t *testing.T) {
+	t.Parallel()
+
+	c := NewClient()
+
+	calls := 0
+	unsub := c.AddMessageHandler(MessageTypeInfo, func(e interface{}) {
+		calls++
+	})
+
+	c.Dispatch(MessageTypeInfo, InfoMessage{})
+	unsub()
+	unsub()
+	c.Dispatch(MessageTypeInfo, InfoMessage{})
+
+	assert.Equal(t, 1, calls)
+	assert.Empty(t, c.handlers[MessageTypeInfo])
+}
+
+func TestClient_AddMessageHandlerOnce(t *testing.T) {
+	t.Parallel()
+
+	c := NewClient()
+
+	calls := 0
+	c.AddMessageHandlerOnce(MessageTypeInfo, func(e interface{}) {
+		calls++
+
+		// dispatching from within the handler must not call it again
+		c.Dispatch(MessageTypeInfo, InfoMessage{})
+	})
+
+	c.Dispatch(MessageTypeInfo, InfoMessage{})
+	c.Dispatch(MessageTypeInfo, InfoMessage{})
+
+	assert.Equal(t, 1, calls)
+}
+
+func TestUnsubscribeOnDone(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	removed := make(chan bool, 2)
+
+	UnsubscribeOnDone(ctx,
+		func() { removed <- true },
+		func() { removed <- true },
+	)
+
+	select {
+	case <-removed:
+		t.Fatal("handler removed before the context was done")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	cancel()
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-removed:
+		case <-time.After(time.Second):
+			t.Fatal("handlers not removed once the context was done")
+		}
+	}
+}
+
+func TestUnsubscribeOnDone_unsubscribe(t *testing.T) {
+	t.Parallel()
+
+	removed := 0
+	unsub := UnsubscribeOnDone(context.Background(), func() {
+		removed++
+	})
+
+	// a context that is never done has its handlers removed by calling the Unsubscribe
+	unsub()
+	unsub()
+
+	assert.Equal(t, 1, removed)
+}
@@ -8,15 +8,56 @@ type ClientMessageType int
 
 const (
 	MessageTypeAction ClientMessageType = iota
+	MessageTypeBroadcast
+	MessageTypeChoiceUpdate
 	MessageTypeClosePlugin
+	MessageTypeConnectorChange
+	MessageTypeConnectorUpdate
+	MessageTypeCreateState
+	MessageTypeDown
 	MessageTypeInfo
+	MessageTypeListChange
+	MessageTypeNotificationOptionClicked
 	MessageTypePair
+	MessageTypeRemoveState
 	MessageTypeSettings
+	MessageTypeSettingUpdate
+	MessageTypeShowNotification
 	MessageTypeStateUpdate
+	MessageTypeTriggerEvent
+	MessageTypeUnknown
+	MessageTypeUp
+	MessageTypeUpdateActionData
 )
 
 type Message struct {
 	Type ClientMessageType `json:"type"`
+
+	// extra is held behind a pointer so messages can still be compared using ==
+	extra *map[string]json.RawMessage
+}
+
+// Extra returns any fields of an incoming message that the SDK does not know about, such
+// as those added by a newer version of TouchPortal, by their JSON name.
+func (m Message) Extra() map[string]json.RawMessage {
+	if m.extra == nil {
+		return nil
+	}
+
+	extra := make(map[string]json.RawMessage, len(*m.extra))
+	for name, value := range *m.extra {
+		extra[name] = value
+	}
+
+	return extra
+}
+
+func (m Message) messageType() ClientMessageType {
+	return m.Type
+}
+
+func (m *Message) setExtra(extra map[string]json.RawMessage) {
+	m.extra = &extra
 }
 
 type ActionMessage struct {
@@ -26,6 +67,25 @@ type ActionMessage struct {
 	Data     json.RawMessage `json:"data"`
 }
 
+// BroadcastMessage is sent by TouchPortal to every plugin when something of general
+// interest happens, such as the user changing page on their device. Event names the
+// broadcast; PageName is only set for the "pageChange" event.
+type BroadcastMessage struct {
+	Message
+	Event    string `json:"event"`
+	PageName string `json:"pageName"`
+}
+
+// ConnectorChangeMessage is sent by TouchPortal whilst a user moves a slider bound to
+// one of the plugins connectors. The value is always within the range 0-100.
+type ConnectorChangeMessage struct {
+	Message
+	PluginID    string          `json:"pluginId"`
+	ConnectorID string          `json:"connectorId"`
+	Value       int             `json:"value"`
+	Data        json.RawMessage `json:"data"`
+}
+
 type ClosePluginMessage struct {
 	Message
 	PluginID string `json:"pluginId"`
@@ -40,6 +100,40 @@ type InfoMessage struct {
 	Settings      json.RawMessage `json:"settings"`
 }
 
+// ListChangeMessage is sent by TouchPortal when the user changes the selected value of
+// a choice list in one of the plugins actions whilst editing a button.
+type ListChangeMessage struct {
+	Message
+	PluginID   string `json:"pluginId"`
+	ActionID   string `json:"actionId"`
+	ListID     string `json:"listId"`
+	InstanceID string `json:"instanceId"`
+	Value      string `json:"value"`
+}
+
+// NotificationOptionClickedMessage is sent by TouchPortal when the user clicks one of the
+// options of a notification shown by the plugin.
+type NotificationOptionClickedMessage struct {
+	Message
+	NotificationID string `json:"notificationId"`
+	OptionID       string `json:"optionId"`
+}
+
+// UnknownMessage is an incoming message of a type the SDK does not know about, such as
+// one added by a newer version of TouchPortal. TypeName is the type TouchPortal sent and
+// Raw the message as it was received.
+type UnknownMessage struct {
+	Message
+	TypeName string          `json:"-"`
+	Raw      json.RawMessage `json:"-"`
+}
+
+// RawMessage is an incoming message as it was received, before any processing
+type RawMessage struct {
+	TypeName string
+	Raw      json.RawMessage
+}
+
 type pairMessage struct {
 	Message
 	ID string `json:"id"`
@@ -75,3 +169,160 @@ func NewStateUpdateMessage(id string, value string) *stateUpdateMessage {
 		Value:   value,
 	}
 }
+
+type createStateMessage struct {
+	Message
+	ID           string `json:"id"`
+	Description  string `json:"desc"`
+	DefaultValue string `json:"defaultValue"`
+	ParentGroup  string `json:"parentGroup,omitempty"`
+}
+
+// NewCreateStateMessage provides a ready to go client.createStateMessage that can be sent to
+// TouchPortal to add a state at runtime. The parentGroup, which may be empty, names the
+// group the state is listed under.
+func NewCreateStateMessage(id, description, defaultValue, parentGroup string) *createStateMessage {
+	return &createStateMessage{
+		Message:      Message{Type: MessageTypeCreateState},
+		ID:           id,
+		Description:  description,
+		DefaultValue: defaultValue,
+		ParentGroup:  parentGroup,
+	}
+}
+
+type removeStateMessage struct {
+	Message
+	ID string `json:"id"`
+}
+
+// NewRemoveStateMessage provides a ready to go client.removeStateMessage that can be sent to
+// TouchPortal to remove a state added at runtime.
+func NewRemoveStateMessage(id string) *removeStateMessage {
+	return &removeStateMessage{
+		Message: Message{Type: MessageTypeRemoveState},
+		ID:      id,
+	}
+}
+
+type choiceUpdateMessage struct {
+	Message
+	ID         string   `json:"id"`
+	Value      []string `json:"value"`
+	InstanceID string   `json:"instanceId,omitempty"`
+}
+
+// NewChoiceUpdateMessage provides a ready to go client.choiceUpdateMessage that can be sent to
+// TouchPortal to replace the values of a choice list. When instanceID is not empty only the
+// list of that action instance is changed.
+func NewChoiceUpdateMessage(id string, values []string, instanceID string) *choiceUpdateMessage {
+	return &choiceUpdateMessage{
+		Message:    Message{Type: MessageTypeChoiceUpdate},
+		ID:         id,
+		Value:      values,
+		InstanceID: instanceID,
+	}
+}
+
+type settingUpdateMessage struct {
+	Message
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// NewSettingUpdateMessage provides a ready to go client.settingUpdateMessage that can be sent to
+// TouchPortal to change the value of one of the plugins settings.
+func NewSettingUpdateMessage(name, value string) *settingUpdateMessage {
+	return &settingUpdateMessage{
+		Message: Message{Type: MessageTypeSettingUpdate},
+		Name:    name,
+		Value:   value,
+	}
+}
+
+type connectorUpdateMessage struct {
+	Message
+	ConnectorID string `json:"connectorId"`
+	Value       int    `json:"value"`
+}
+
+// NewConnectorUpdateMessage provides a ready to go client.connectorUpdateMessage that can be sent
+// to TouchPortal to move the sliders bound to a connector. The connectorID is the full id
+// TouchPortal expects, including the plugin id prefix and any data.
+func NewConnectorUpdateMessage(connectorID string, value int) *connectorUpdateMessage {
+	return &connectorUpdateMessage{
+		Message:     Message{Type: MessageTypeConnectorUpdate},
+		ConnectorID: connectorID,
+		Value:       value,
+	}
+}
+
+type triggerEventMessage struct {
+	Message
+	EventID string            `json:"eventId"`
+	States  map[string]string `json:"states,omitempty"`
+}
+
+// NewTriggerEventMessage provides a ready to go client.triggerEventMessage that can be sent to
+// TouchPortal to trigger one of the plugins events, passing it any local states.
+func NewTriggerEventMessage(eventID string, states map[string]string) *triggerEventMessage {
+	return &triggerEventMessage{
+		Message: Message{Type: MessageTypeTriggerEvent},
+		EventID: eventID,
+		States:  states,
+	}
+}
+
+// ActionDataUpdate describes the new range of a number data field of an action instance
+type ActionDataUpdate struct {
+	ID       string  `json:"id"`
+	Type     string  `json:"type"`
+	MinValue float64 `json:"minValue"`
+	MaxValue float64 `json:"maxValue"`
+}
+
+type updateActionDataMessage struct {
+	Message
+	InstanceID string           `json:"instanceId"`
+	Data       ActionDataUpdate `json:"data"`
+}
+
+// NewUpdateActionDataMessage provides a ready to go client.updateActionDataMessage that can be
+// sent to TouchPortal to change the range of a data field of an action instance.
+func NewUpdateActionDataMessage(instanceID string, data ActionDataUpdate) *updateActionDataMessage {
+	if data.Type == "" {
+		data.Type = "number"
+	}
+
+	return &updateActionDataMessage{
+		Message:    Message{Type: MessageTypeUpdateActionData},
+		InstanceID: instanceID,
+		Data:       data,
+	}
+}
+
+// NotificationOption is one of the options the user can click on a notification
+type NotificationOption struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
+
+type showNotificationMessage struct {
+	Message
+	NotificationID string               `json:"notificationId"`
+	Title          string               `json:"title"`
+	Body           string               `json:"msg"`
+	Options        []NotificationOption `json:"options"`
+}
+
+// NewShowNotificationMessage provides a ready to go client.showNotificationMessage that can be
+// sent to TouchPortal to show the user a notification.
+func NewShowNotificationMessage(id, title, body string, options []NotificationOption) *showNotificationMessage {
+	return &showNotificationMessage{
+		Message:        Message{Type: MessageTypeShowNotification},
+		NotificationID: id,
+		Title:          title,
+		Body:           body,
+		Options:        options,
+	}
+}
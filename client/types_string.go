@@ -9,9 +9,9 @@ import (
 	"fmt"
 )
 
-const _ClientMessageTypeName = "actionclosePlugininfopairsettingsstateUpdate"
+const _ClientMessageTypeName = "actionbroadcastchoiceUpdateclosePluginconnectorChangeconnectorUpdatecreateStatedowninfolistChangenotificationOptionClickedpairremoveStatesettingssettingUpdateshowNotificationstateUpdatetriggerEventunknownupupdateActionData"
 
-var _ClientMessageTypeIndex = [...]uint8{0, 6, 17, 21, 25, 33, 44}
+var _ClientMessageTypeIndex = [...]uint8{0, 6, 15, 27, 38, 53, 68, 79, 83, 87, 97, 122, 126, 137, 145, 158, 174, 185, 197, 204, 206, 222}
 
 func (i ClientMessageType) String() string {
 	if i < 0 || i >= ClientMessageType(len(_ClientMessageTypeIndex)-1) {
@@ -20,17 +20,32 @@ func (i ClientMessageType) String() string {
 	return _ClientMessageTypeName[_ClientMessageTypeIndex[i]:_ClientMessageTypeIndex[i+1]]
 }
 
-var _ClientMessageTypeValues = []ClientMessageType{0, 1, 2, 3, 4, 5}
+var _ClientMessageTypeValues = []ClientMessageType{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20}
 
-var _ClientMessageTypeNames = []string{"action", "closePlugin", "info", "pair", "settings", "stateUpdate"}
+var _ClientMessageTypeNames = []string{"action", "broadcast", "choiceUpdate", "closePlugin", "connectorChange", "connectorUpdate", "createState", "down", "info", "listChange", "notificationOptionClicked", "pair", "removeState", "settings", "settingUpdate", "showNotification", "stateUpdate", "triggerEvent", "unknown", "up", "updateActionData"}
 
 var _ClientMessageTypeNameToValueMap = map[string]ClientMessageType{
-	_ClientMessageTypeName[0:6]:   0,
-	_ClientMessageTypeName[6:17]:  1,
-	_ClientMessageTypeName[17:21]: 2,
-	_ClientMessageTypeName[21:25]: 3,
-	_ClientMessageTypeName[25:33]: 4,
-	_ClientMessageTypeName[33:44]: 5,
+	_ClientMessageTypeName[0:6]:     0,
+	_ClientMessageTypeName[6:15]:    1,
+	_ClientMessageTypeName[15:27]:   2,
+	_ClientMessageTypeName[27:38]:   3,
+	_ClientMessageTypeName[38:53]:   4,
+	_ClientMessageTypeName[53:68]:   5,
+	_ClientMessageTypeName[68:79]:   6,
+	_ClientMessageTypeName[79:83]:   7,
+	_ClientMessageTypeName[83:87]:   8,
+	_ClientMessageTypeName[87:97]:   9,
+	_ClientMessageTypeName[97:122]:  10,
+	_ClientMessageTypeName[122:126]: 11,
+	_ClientMessageTypeName[126:137]: 12,
+	_ClientMessageTypeName[137:145]: 13,
+	_ClientMessageTypeName[145:158]: 14,
+	_ClientMessageTypeName[158:174]: 15,
+	_ClientMessageTypeName[174:185]: 16,
+	_ClientMessageTypeName[185:197]: 17,
+	_ClientMessageTypeName[197:204]: 18,
+	_ClientMessageTypeName[204:206]: 19,
+	_ClientMessageTypeName[206:222]: 20,
 }
 
 // ClientMessageTypeString retrieves an enum value from the enum constants string name.
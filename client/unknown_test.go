@@ -0,0 +1,81 @@
+package client
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_processMessage_unknownType(t *testing.T) {
+	t.Parallel()
+
+	c := NewClient()
+
+	var got []UnknownMessage
+	Handle(c, func(e UnknownMessage) {
+		got = append(got, e)
+	})
+
+	raw := json.RawMessage(`{"type":"somethingNew","value":1}`)
+	c.processMessage(raw)
+
+	assert.Equal(t, []UnknownMessage{{
+		Message:  Message{Type: MessageTypeUnknown},
+		TypeName: "somethingNew",
+		Raw:      raw,
+	}}, got)
+}
+
+func TestClient_processMessage_extraFields(t *testing.T) {
+	t.Parallel()
+
+	c := NewClient()
+
+	var got ConnectorChangeMessage
+	Handle(c, func(e ConnectorChangeMessage) {
+		got = e
+	})
+
+	c.processMessage(json.RawMessage(`{"type":"connectorChange","ConnectorId":"slider","value":50,"shortId":"abc"}`))
+
+	assert.Equal(t, "slider", got.ConnectorID)
+	assert.Equal(t, 50, got.Value)
+	assert.Equal(t, map[string]json.RawMessage{"shortId": json.RawMessage(`"abc"`)}, got.Extra())
+}
+
+func TestMessage_comparable(t *testing.T) {
+	t.Parallel()
+
+	c := NewClient()
+
+	var got []BroadcastMessage
+	Handle(c, func(e BroadcastMessage) {
+		got = append(got, e)
+	})
+
+	c.processMessage(json.RawMessage(`{"type":"broadcast","event":"pageChange","pageName":"main"}`))
+	c.processMessage(json.RawMessage(`{"type":"broadcast","event":"pageChange","pageName":"main","deviceId":"phone"}`))
+
+	assert.Len(t, got, 2)
+	assert.True(t, got[0] == BroadcastMessage{Message: Message{Type: MessageTypeBroadcast}, Event: "pageChange", PageName: "main"})
+	assert.False(t, got[0] == got[1], "messages with extra fields compared equal to one without")
+}
+
+func TestClient_OnAny(t *testing.T) {
+	t.Parallel()
+
+	c := NewClient()
+
+	var got []string
+	unsub := c.OnAny(func(msg RawMessage) {
+		got = append(got, msg.TypeName)
+	})
+
+	c.processMessage(json.RawMessage(`{"type":"info"}`))
+	c.processMessage(json.RawMessage(`{"type":"somethingNew"}`))
+	unsub()
+	c.processMessage(json.RawMessage(`{"type":"info"}`))
+
+	assert.Equal(t, []string{"info", "somethingNew"}, got)
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultTimeout is how long a command may run when its action sets no timeout
+const defaultTimeout = 30 * time.Second
+
+// config is the description of the plugin and the commands each of its actions run. It
+// may be written as YAML or JSON.
+type config struct {
+	ID         string `json:"id" yaml:"id"`
+	Name       string `json:"name" yaml:"name"`
+	Version    int    `json:"version" yaml:"version"`
+	ColorDark  string `json:"colorDark,omitempty" yaml:"colorDark,omitempty"`
+	ColorLight string `json:"colorLight,omitempty" yaml:"colorLight,omitempty"`
+	// StartCmd overrides the command TouchPortal uses to start the plugin
+	StartCmd string         `json:"startCmd,omitempty" yaml:"startCmd,omitempty"`
+	Actions  []actionConfig `json:"actions" yaml:"actions"`
+}
+
+// actionConfig is a single action and the command it runs. The command, args, dir and
+// env values are templates given the actions data as .Data, and its id as .ActionID.
+type actionConfig struct {
+	ID      string            `json:"id" yaml:"id"`
+	Name    string            `json:"name" yaml:"name"`
+	Command string            `json:"command" yaml:"command"`
+	Args    []string          `json:"args,omitempty" yaml:"args,omitempty"`
+	Dir     string            `json:"dir,omitempty" yaml:"dir,omitempty"`
+	Env     map[string]string `json:"env,omitempty" yaml:"env,omitempty"`
+	Timeout duration          `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	Data    []dataConfig      `json:"data,omitempty" yaml:"data,omitempty"`
+}
+
+// dataConfig is a field the user fills in when adding the action to a button
+type dataConfig struct {
+	ID      string   `json:"id" yaml:"id"`
+	Label   string   `json:"label,omitempty" yaml:"label,omitempty"`
+	Default string   `json:"default,omitempty" yaml:"default,omitempty"`
+	Choices []string `json:"choices,omitempty" yaml:"choices,omitempty"`
+}
+
+// duration is a time.Duration written as a string, such as "1m30s"
+type duration time.Duration
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface, used by both the
+// JSON and YAML decoders
+func (d *duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+
+	*d = duration(v)
+
+	return nil
+}
+
+// MarshalText implements the encoding.TextMarshaler interface
+func (d duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// loadConfig reads the config at path, as JSON if it has a .json extension and as YAML
+// otherwise, and checks it is usable.
+func loadConfig(path string) (*config, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &config{}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = json.Unmarshal(b, cfg)
+	} else {
+		err = yaml.Unmarshal(b, cfg)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse %s: %w", path, err)
+	}
+
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+
+	return cfg, nil
+}
+
+func (c *config) validate() error {
+	var errs []error
+	if c.ID == "" {
+		errs = append(errs, errors.New("the plugin id is required"))
+	}
+
+	seen := make(map[string]bool, len(c.Actions))
+	for i, a := range c.Actions {
+		if a.ID == "" || a.Command == "" {
+			errs = append(errs, fmt.Errorf("action %d: an id and command are required", i))
+			continue
+		}
+
+		if seen[a.ID] {
+			errs = append(errs, fmt.Errorf("action %q: defined more than once", a.ID))
+		}
+		seen[a.ID] = true
+
+		if a.Timeout < 0 {
+			errs = append(errs, fmt.Errorf("action %q: the timeout cannot be negative", a.ID))
+		}
+
+		for _, text := range a.templates() {
+			if _, err := template.New("").Parse(text); err != nil {
+				errs = append(errs, fmt.Errorf("action %q: %w", a.ID, err))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// templates returns every templated value of the action
+func (a actionConfig) templates() []string {
+	texts := append([]string{a.Command, a.Dir}, a.Args...)
+	for _, v := range a.Env {
+		texts = append(texts, v)
+	}
+
+	return texts
+}
+
+// timeout returns how long the actions command may run for
+func (a actionConfig) timeout() time.Duration {
+	if a.Timeout == 0 {
+		return defaultTimeout
+	}
+
+	return time.Duration(a.Timeout)
+}
+
+// stdoutState is the id of the state holding the output of the actions last command
+func stdoutState(actionID string) string {
+	return actionID + "_stdout"
+}
+
+// stderrState is the id of the state holding the error output of the actions last command
+func stderrState(actionID string) string {
+	return actionID + "_stderr"
+}
+
+// exitState is the id of the state holding the exit status of the actions last command,
+// empty whilst the command runs
+func exitState(actionID string) string {
+	return actionID + "_exit"
+}
+
+// exitEvent is the id of the event fired each time the actions command finishes
+func exitEvent(actionID string) string {
+	return actionID + "_finished"
+}
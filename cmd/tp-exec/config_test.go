@@ -0,0 +1,110 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const yamlConfig = `
+id: shell
+name: Shell
+version: 2
+actions:
+  - id: shell_ping
+    name: Ping host
+    command: ping
+    args: ["-c", "1", "{{.Data.host}}"]
+    timeout: 10s
+    data:
+      - id: host
+        label: Host
+        default: localhost
+  - id: shell_mode
+    command: set-mode
+    data:
+      - id: mode
+        choices: [on, off]
+`
+
+const jsonConfig = `{
+  "id": "shell",
+  "actions": [{"id": "shell_date", "command": "date", "timeout": "1s"}]
+}`
+
+func writeConfig(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := loadConfig(writeConfig(t, "shell.yaml", yamlConfig))
+	assert.NoError(t, err)
+	assert.Equal(t, "shell", cfg.ID)
+	assert.Len(t, cfg.Actions, 2)
+	assert.Equal(t, []string{"-c", "1", "{{.Data.host}}"}, cfg.Actions[0].Args)
+	assert.Equal(t, 10*time.Second, cfg.Actions[0].timeout())
+	assert.Equal(t, defaultTimeout, cfg.Actions[1].timeout())
+
+	cfg, err = loadConfig(writeConfig(t, "shell.json", jsonConfig))
+	assert.NoError(t, err)
+	assert.Equal(t, time.Second, cfg.Actions[0].timeout())
+}
+
+func TestLoadConfig_invalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "missing id", content: "actions: []"},
+		{name: "missing command", content: "id: shell\nactions: [{id: a}]"},
+		{name: "duplicate action", content: "id: shell\nactions: [{id: a, command: x}, {id: a, command: y}]"},
+		{name: "invalid template", content: "id: shell\nactions: [{id: a, command: x, args: ['{{.Data']}]"},
+		{name: "invalid timeout", content: "id: shell\nactions: [{id: a, command: x, timeout: soon}]"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := loadConfig(writeConfig(t, "shell.yaml", tt.content))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestConfig_description(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := loadConfig(writeConfig(t, "shell.yaml", yamlConfig))
+	assert.NoError(t, err)
+
+	d := cfg.description()
+	assert.Equal(t, "shell", d.ID)
+	assert.Equal(t, 2, d.Version)
+	assert.Equal(t, "%TP_PLUGIN_FOLDER%shell/tp-exec", d.StartCmd)
+
+	ping, ok := d.Action("shell_ping")
+	assert.True(t, ok)
+	assert.Equal(t, "Ping host {$host$}", ping.Format)
+	assert.Equal(t, "text", ping.Data[0].Type)
+
+	mode, ok := d.Action("shell_mode")
+	assert.True(t, ok)
+	assert.Equal(t, "choice", mode.Data[0].Type)
+	assert.Equal(t, "on", string(mode.Data[0].Default))
+
+	c := d.Categories[0]
+	assert.Len(t, c.States, 6)
+	assert.Equal(t, "shell_ping_exit", c.Events[0].ValueStateID)
+}
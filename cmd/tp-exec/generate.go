@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/marcokaiser/touchportal-golang-sdk/entry"
+)
+
+// entrySDK is the version of the TouchPortal API the generated entry.tp declares
+const entrySDK = 6
+
+// description builds the entry.tp of the plugin described by the config. Every action
+// has states for the output and exit status of its command, and an event fired each time
+// the command finishes.
+func (c *config) description() *entry.Description {
+	name := c.Name
+	if name == "" {
+		name = c.ID
+	}
+
+	startCmd := c.StartCmd
+	if startCmd == "" {
+		startCmd = fmt.Sprintf("%%TP_PLUGIN_FOLDER%%%s/tp-exec", c.ID)
+	}
+
+	category := entry.Category{
+		ID:         c.ID + "_main",
+		Name:       name,
+		Actions:    []entry.Action{},
+		Events:     []entry.Event{},
+		Connectors: []entry.Connector{},
+		States:     []entry.State{},
+	}
+
+	for _, a := range c.Actions {
+		actionName := a.Name
+		if actionName == "" {
+			actionName = a.ID
+		}
+
+		action := entry.Action{
+			ID:     a.ID,
+			Name:   actionName,
+			Prefix: name,
+			Type:   "communicate",
+			Format: actionName,
+		}
+
+		if len(a.Data) > 0 {
+			action.TryInline = true
+
+			fields := make([]string, 0, len(a.Data))
+			for _, d := range a.Data {
+				action.Data = append(action.Data, d.entryData())
+				fields = append(fields, "{$"+d.ID+"$}")
+			}
+			action.Format += " " + strings.Join(fields, " ")
+		}
+
+		category.Actions = append(category.Actions, action)
+
+		category.States = append(category.States,
+			entry.State{ID: stdoutState(a.ID), Type: "text", Desc: actionName + " output"},
+			entry.State{ID: stderrState(a.ID), Type: "text", Desc: actionName + " error output"},
+			entry.State{ID: exitState(a.ID), Type: "text", Desc: actionName + " exit status"},
+		)
+
+		category.Events = append(category.Events, entry.Event{
+			ID:           exitEvent(a.ID),
+			Name:         actionName + " finished",
+			Format:       "When " + actionName + " exits with status $val",
+			Type:         "communicate",
+			ValueType:    "text",
+			ValueStateID: exitState(a.ID),
+		})
+	}
+
+	return &entry.Description{
+		SDK:     entrySDK,
+		Version: c.Version,
+		Name:    name,
+		ID:      c.ID,
+		Configuration: entry.Configuration{
+			ColorDark:  c.ColorDark,
+			ColorLight: c.ColorLight,
+		},
+		StartCmd:   startCmd,
+		Categories: []entry.Category{category},
+	}
+}
+
+func (d dataConfig) entryData() entry.Data {
+	ed := entry.Data{
+		ID:      d.ID,
+		Type:    "text",
+		Label:   d.Label,
+		Default: entry.Value(d.Default),
+	}
+
+	if len(d.Choices) > 0 {
+		ed.Type = "choice"
+		ed.ValueChoices = d.Choices
+		if ed.Default == "" {
+			ed.Default = entry.Value(d.Choices[0])
+		}
+	}
+
+	return ed
+}
+
+// writeEntry writes the entry.tp of the config to path
+func writeEntry(c *config, path string) error {
+	b, err := json.MarshalIndent(c.description(), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append(b, '\n'), 0o644)
+}
@@ -0,0 +1,83 @@
+// Command tp-exec is a plugin that runs a command for each of its actions, as described
+// by a YAML or JSON config, so buttons can run scripts and programs without writing a
+// plugin for them.
+//
+//	id: shell
+//	name: Shell
+//	version: 1
+//	actions:
+//	  - id: shell_ping
+//	    name: Ping host
+//	    command: ping
+//	    args: ["-c", "1", "{{.Data.host}}"]
+//	    timeout: 10s
+//	    data:
+//	      - id: host
+//	        label: Host
+//	        default: localhost
+//
+// The output, error output and exit status of each command are written to the states
+// <action id>_stdout, <action id>_stderr and <action id>_exit, the event
+// <action id>_finished firing each time the command finishes. The exit status is empty
+// whilst the command runs, so the event fires even when it exits as it did last time.
+//
+// The matching entry.tp is generated from the config using the -entry flag.
+//
+//	tp-exec -config shell.yaml -entry entry.tp
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+
+	"github.com/marcokaiser/touchportal-golang-sdk/client"
+	"github.com/marcokaiser/touchportal-golang-sdk/plugin"
+)
+
+func main() {
+	configPath := flag.String("config", defaultConfigPath(), "path to the YAML or JSON config of the plugins actions")
+	entryPath := flag.String("entry", "", "write the entry.tp for the config to this path and exit")
+	flag.Parse()
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		log.Fatalf("unable to load config: %v", err)
+	}
+
+	if *entryPath != "" {
+		if err := writeEntry(cfg, *entryPath); err != nil {
+			log.Fatalf("unable to write entry.tp: %v", err)
+		}
+
+		return
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	p := plugin.NewPlugin(ctx, cfg.ID)
+
+	r := &runner{plugin: p, logger: client.DefaultLogger()}
+	defer r.register(ctx, cfg)()
+
+	if err := p.Register(ctx); err != nil {
+		log.Fatalf("unable to register plugin with TouchPortal: %v", err)
+	}
+
+	<-p.Done()
+}
+
+// defaultConfigPath is tp-exec.yaml in the folder of the executable, where TouchPortal
+// installs it along with the plugin
+func defaultConfigPath() string {
+	exe, err := os.Executable()
+	if err != nil {
+		return "tp-exec.yaml"
+	}
+
+	return filepath.Join(filepath.Dir(exe), "tp-exec.yaml")
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/marcokaiser/touchportal-golang-sdk/client"
+	"github.com/marcokaiser/touchportal-golang-sdk/plugin"
+)
+
+// maxOutput is the most output of a command written to a state, only the end of longer
+// output being kept
+const maxOutput = 4096
+
+// templateData is given to the templated values of an action
+type templateData struct {
+	ActionID string
+	Data     map[string]string
+}
+
+// result is the outcome of running an actions command
+type result struct {
+	stdout string
+	stderr string
+	// exit is the exit status of the command, or -1 if it could not be started or was
+	// stopped after running for too long
+	exit int
+}
+
+// runner runs the commands of the configured actions as they are handled
+type runner struct {
+	plugin *plugin.Plugin
+	logger client.Logger
+}
+
+// register adds a handler for every action of the config, returning a function that
+// removes them all again
+func (r *runner) register(ctx context.Context, cfg *config) client.Unsubscribe {
+	unsubs := make([]client.Unsubscribe, 0, len(cfg.Actions))
+	for _, a := range cfg.Actions {
+		a := a
+
+		unsubs = append(unsubs, r.plugin.OnAction(func(event client.ActionMessage) {
+			data, err := event.Values()
+			if err != nil {
+				r.logger.Warn("unable to read action data", "actionId", a.ID, "error", err)
+				return
+			}
+
+			// commands may take a while, so are run without holding up other messages
+			go r.handle(ctx, a, data)
+		}, a.ID))
+	}
+
+	return func() {
+		for _, unsub := range unsubs {
+			unsub()
+		}
+	}
+}
+
+// handle runs the actions command, writing its result to the actions states
+func (r *runner) handle(ctx context.Context, a actionConfig, data map[string]string) {
+	// touchportal only fires the finished event when the exit status changes, so it is
+	// cleared whilst the command runs for the event to fire for every run
+	if err := r.plugin.UpdateState(exitState(a.ID), ""); err != nil {
+		r.logger.Warn("unable to update state", "stateId", exitState(a.ID), "error", err)
+	}
+
+	res := run(ctx, a, data)
+
+	r.logger.Debug("ran action command", "actionId", a.ID, "exit", res.exit)
+
+	for id, value := range map[string]string{
+		stdoutState(a.ID): res.stdout,
+		stderrState(a.ID): res.stderr,
+	} {
+		if err := r.plugin.UpdateState(id, value); err != nil {
+			r.logger.Warn("unable to update state", "stateId", id, "error", err)
+		}
+	}
+
+	// the exit status is updated last as its event is how buttons know the command has
+	// finished, by which time the output should be there too
+	if err := r.plugin.UpdateState(exitState(a.ID), strconv.Itoa(res.exit)); err != nil {
+		r.logger.Warn("unable to update state", "stateId", exitState(a.ID), "error", err)
+	}
+}
+
+// run runs the actions command with its templates filled in from data
+func run(ctx context.Context, a actionConfig, data map[string]string) result {
+	td := templateData{ActionID: a.ID, Data: data}
+
+	command, err := render(a.Command, td)
+	if err != nil {
+		return result{stderr: err.Error(), exit: -1}
+	}
+
+	args := make([]string, 0, len(a.Args))
+	for _, arg := range a.Args {
+		v, err := render(arg, td)
+		if err != nil {
+			return result{stderr: err.Error(), exit: -1}
+		}
+		args = append(args, v)
+	}
+
+	dir, err := render(a.Dir, td)
+	if err != nil {
+		return result{stderr: err.Error(), exit: -1}
+	}
+
+	env := os.Environ()
+	for k, text := range a.Env {
+		v, err := render(text, td)
+		if err != nil {
+			return result{stderr: err.Error(), exit: -1}
+		}
+		env = append(env, k+"="+v)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, a.timeout())
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+
+	// no shell is involved, so data can only ever become an argument of the command
+	cmd := exec.CommandContext(ctx, command, args...)
+	cmd.Dir = dir
+	cmd.Env = env
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err = cmd.Run()
+
+	res := result{
+		stdout: tail(stdout.String()),
+		stderr: tail(stderr.String()),
+	}
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		res.exit = -1
+		res.stderr = tail(strings.TrimSpace(res.stderr + "\ncommand timed out after " + a.timeout().String()))
+	case errors.As(err, &exitErr):
+		res.exit = exitErr.ExitCode()
+	default:
+		res.exit = -1
+		res.stderr = tail(err.Error())
+	}
+
+	return res
+}
+
+// render fills in the template text with data. Data missing from the action is left
+// empty, rather than written as "<no value>".
+func render(text string, data templateData) (string, error) {
+	t, err := template.New("").Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// tail trims the output of surrounding whitespace, keeping only the end of it if it is
+// longer than maxOutput
+func tail(output string) string {
+	output = strings.TrimSpace(output)
+	if len(output) > maxOutput {
+		output = output[len(output)-maxOutput:]
+	}
+
+	return output
+}
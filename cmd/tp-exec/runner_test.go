@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/marcokaiser/touchportal-golang-sdk/client"
+	"github.com/marcokaiser/touchportal-golang-sdk/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		action actionConfig
+		data   map[string]string
+		want   result
+	}{
+		{
+			name:   "templated arguments",
+			action: actionConfig{ID: "a", Command: "echo", Args: []string{"hello", "{{.Data.name}}", "{{.Data.missing}}"}},
+			data:   map[string]string{"name": "world"},
+			want:   result{stdout: "hello world"},
+		},
+		{
+			name:   "environment and exit status",
+			action: actionConfig{ID: "a", Command: "sh", Args: []string{"-c", "echo $GREETING >&2; exit 3"}, Env: map[string]string{"GREETING": "hi {{.ActionID}}"}},
+			want:   result{stderr: "hi a", exit: 3},
+		},
+		{
+			name:   "missing command",
+			action: actionConfig{ID: "a", Command: "tp-exec-does-not-exist"},
+			want:   result{stderr: `exec: "tp-exec-does-not-exist": executable file not found in $PATH`, exit: -1},
+		},
+		{
+			name:   "timeout",
+			action: actionConfig{ID: "a", Command: "sleep", Args: []string{"5"}, Timeout: duration(50 * time.Millisecond)},
+			want:   result{stderr: "command timed out after 50ms", exit: -1},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, run(context.Background(), tt.action, tt.data))
+		})
+	}
+}
+
+func TestRunner(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	p := plugintest.New(t, "shell")
+
+	exits := make(chan string, 4)
+	p.OnStateUpdate(func(id, value string) {
+		if id == "shell_echo_exit" {
+			exits <- value
+		}
+	})
+
+	run := &runner{plugin: p.Plugin, logger: client.DefaultLogger()}
+	unsub := run.register(ctx, &config{
+		ID:      "shell",
+		Actions: []actionConfig{{ID: "shell_echo", Command: "echo", Args: []string{"{{.Data.text}}"}}},
+	})
+	defer unsub()
+
+	// running the same command twice changes the exit status both times, so touchportal
+	// fires the finished event for each
+	for _, text := range []string{"hello", "again"} {
+		assert.NoError(t, p.DispatchAction("shell_echo", map[string]string{"text": text}))
+
+		for _, want := range []string{"", "0"} {
+			select {
+			case exit := <-exits:
+				assert.Equal(t, want, exit)
+			case <-time.After(5 * time.Second):
+				t.Fatal("command did not finish")
+			}
+		}
+
+		stdout, _ := p.State("shell_echo_stdout")
+		assert.Equal(t, text, stdout)
+	}
+}
@@ -0,0 +1,117 @@
+// Command tpsim simulates the TouchPortal side of the plugin API so plugins can be
+// developed and exercised on machines where TouchPortal itself cannot run.
+//
+// It reads the plugins entry.tp, listens where TouchPortal would, optionally launches
+// the plugin, completes the pairing handshake and then offers an interactive prompt to
+// fire actions, move connectors, change settings and inspect state values.
+//
+//	tpsim -entry example/darwin/entry.tp -setting Host=localhost -exec "go run ./example"
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/marcokaiser/touchportal-golang-sdk/entry"
+)
+
+// settingFlags collects repeated -setting Name=Value flags
+type settingFlags map[string]string
+
+func (s settingFlags) String() string {
+	return fmt.Sprint(map[string]string(s))
+}
+
+func (s settingFlags) Set(v string) error {
+	name, value, ok := strings.Cut(v, "=")
+	if !ok {
+		return fmt.Errorf("settings must be given as Name=Value, got %q", v)
+	}
+
+	s[name] = value
+
+	return nil
+}
+
+func main() {
+	settings := settingFlags{}
+
+	entryPath := flag.String("entry", "entry.tp", "path to the plugins entry.tp")
+	addr := flag.String("addr", "127.0.0.1:12136", "address to listen on for the plugin")
+	command := flag.String("exec", "", "command used to launch the plugin, if not started separately")
+	flag.Var(settings, "setting", "setting value given to the plugin as Name=Value, may be repeated")
+	flag.Parse()
+
+	d, err := entry.Load(*entryPath)
+	if err != nil {
+		log.Fatalf("unable to load entry.tp: %v", err)
+	}
+
+	l, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.Fatalf("unable to listen on %s: %v", *addr, err)
+	}
+	defer l.Close()
+
+	var cmd *exec.Cmd
+	if *command != "" {
+		cmd = launch(*command, *entryPath)
+	}
+
+	fmt.Printf("waiting for plugin %q to connect on %s\n", d.ID, l.Addr())
+
+	conn, err := l.Accept()
+	if err != nil {
+		log.Fatalf("unable to accept plugin connection: %v", err)
+	}
+	defer conn.Close()
+
+	s := newSession(d, conn, os.Stdout, settings)
+	if err := s.handshake(); err != nil {
+		log.Fatalf("unable to pair with plugin: %v", err)
+	}
+
+	fmt.Printf("plugin %q paired\n", d.ID)
+
+	go func() {
+		if err := s.readLoop(); err != nil {
+			fmt.Printf("connection to plugin failed: %v\n", err)
+		}
+
+		fmt.Println("plugin disconnected")
+		os.Exit(0)
+	}()
+
+	newPrompt(s, os.Stdin, os.Stdout).run()
+
+	if cmd != nil {
+		_ = s.sendClosePlugin()
+		_ = cmd.Wait()
+	}
+}
+
+// launch starts the plugin using the given shell command. As with TouchPortal the
+// TP_PLUGIN_FOLDER environment variable points to the folder plugins are installed in.
+func launch(command string, entryPath string) *exec.Cmd {
+	folder, err := filepath.Abs(filepath.Dir(filepath.Dir(entryPath)))
+	if err != nil {
+		log.Fatalf("unable to determine plugin folder: %v", err)
+	}
+
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(), "TP_PLUGIN_FOLDER="+folder+string(filepath.Separator))
+
+	if err := cmd.Start(); err != nil {
+		log.Fatalf("unable to launch plugin: %v", err)
+	}
+
+	return cmd
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/marcokaiser/touchportal-golang-sdk/client"
+	"github.com/marcokaiser/touchportal-golang-sdk/entry"
+)
+
+const help = `commands:
+  actions                 list the actions and connectors declared in entry.tp
+  action <id>             fire an action, prompting for its data fields
+  hold <id>               press and hold an action
+  release <id>            release a held action
+  connector <id> <0-100>  move a connector, prompting for its data fields
+  settings                show the current settings
+  set <name> <value>      change a setting and notify the plugin
+  states                  show the current state values
+  close                   ask the plugin to shut down
+  quit                    leave the simulator
+`
+
+// prompt is the interactive command loop driving a session
+type prompt struct {
+	session *session
+	in      *bufio.Scanner
+	out     io.Writer
+}
+
+func newPrompt(s *session, in io.Reader, out io.Writer) *prompt {
+	return &prompt{
+		session: s,
+		in:      bufio.NewScanner(in),
+		out:     out,
+	}
+}
+
+// run reads and executes commands until the input ends or the user quits
+func (p *prompt) run() {
+	fmt.Fprint(p.out, help)
+
+	for {
+		fmt.Fprint(p.out, "> ")
+		if !p.in.Scan() {
+			return
+		}
+
+		args := strings.Fields(p.in.Text())
+		if len(args) == 0 {
+			continue
+		}
+
+		if args[0] == "quit" || args[0] == "exit" {
+			return
+		}
+
+		if err := p.execute(args[0], args[1:]); err != nil {
+			fmt.Fprintf(p.out, "error: %v\n", err)
+		}
+	}
+}
+
+func (p *prompt) execute(cmd string, args []string) error {
+	switch cmd {
+	case "help":
+		fmt.Fprint(p.out, help)
+	case "actions":
+		p.listActions()
+	case "action", "hold", "release":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: %s <id>", cmd)
+		}
+
+		return p.fireAction(cmd, args[0])
+	case "connector":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: connector <id> <0-100>")
+		}
+
+		return p.moveConnector(args[0], args[1])
+	case "settings":
+		for _, s := range p.session.settingValues() {
+			for name, value := range s {
+				fmt.Fprintf(p.out, "  %s = %q\n", name, value)
+			}
+		}
+	case "set":
+		if len(args) < 2 {
+			return fmt.Errorf("usage: set <name> <value>")
+		}
+
+		return p.session.updateSetting(args[0], strings.Join(args[1:], " "))
+	case "states":
+		for _, s := range p.session.stateValues() {
+			fmt.Fprintf(p.out, "  %s = %q\n", s.Name, s.Value)
+		}
+	case "close":
+		return p.session.sendClosePlugin()
+	default:
+		return fmt.Errorf("unknown command %q, try help", cmd)
+	}
+
+	return nil
+}
+
+func (p *prompt) listActions() {
+	for _, c := range p.session.entry.Categories {
+		fmt.Fprintf(p.out, "%s:\n", c.Name)
+
+		for _, a := range c.Actions {
+			hold := ""
+			if a.HasHoldFunctionality {
+				hold = " (holdable)"
+			}
+			fmt.Fprintf(p.out, "  action %s - %s%s\n", a.ID, a.Name, hold)
+		}
+
+		for _, con := range c.Connectors {
+			fmt.Fprintf(p.out, "  connector %s - %s\n", con.ID, con.Name)
+		}
+	}
+}
+
+func (p *prompt) fireAction(cmd string, id string) error {
+	a, ok := p.session.entry.Action(id)
+	if !ok {
+		return fmt.Errorf("unknown action %q", id)
+	}
+
+	t := client.MessageTypeAction
+	switch cmd {
+	case "hold":
+		t = client.MessageTypeDown
+	case "release":
+		t = client.MessageTypeUp
+	}
+
+	if t != client.MessageTypeAction && !a.HasHoldFunctionality {
+		fmt.Fprintf(p.out, "warning: action %q does not declare hasHoldFunctionality\n", id)
+	}
+
+	data, err := p.promptData(a.Data)
+	if err != nil {
+		return err
+	}
+
+	return p.session.sendAction(t, id, data)
+}
+
+func (p *prompt) moveConnector(id string, value string) error {
+	con, ok := p.session.entry.Connector(id)
+	if !ok {
+		return fmt.Errorf("unknown connector %q", id)
+	}
+
+	v, err := strconv.Atoi(value)
+	if err != nil || v < 0 || v > 100 {
+		return fmt.Errorf("connector value must be a whole number between 0 and 100")
+	}
+
+	data, err := p.promptData(con.Data)
+	if err != nil {
+		return err
+	}
+
+	return p.session.sendConnectorChange(id, v, data)
+}
+
+// promptData asks the user for a value for each of the given data fields, using the
+// declared default when nothing is entered.
+func (p *prompt) promptData(fields []entry.Data) ([]actionData, error) {
+	data := make([]actionData, 0, len(fields))
+
+	for _, f := range fields {
+		label := f.Label
+		if label == "" {
+			label = f.ID
+		}
+
+		for {
+			if len(f.ValueChoices) > 0 {
+				fmt.Fprintf(p.out, "  %s %v [%s]: ", label, f.ValueChoices, f.Default)
+			} else {
+				fmt.Fprintf(p.out, "  %s (%s) [%s]: ", label, f.Type, f.Default)
+			}
+
+			if !p.in.Scan() {
+				return nil, io.ErrUnexpectedEOF
+			}
+
+			value := strings.TrimSpace(p.in.Text())
+			if value == "" {
+				value = string(f.Default)
+			}
+
+			if err := validateData(f, value); err != nil {
+				fmt.Fprintf(p.out, "  %v\n", err)
+				continue
+			}
+
+			data = append(data, actionData{ID: f.ID, Value: value})
+
+			break
+		}
+	}
+
+	return data, nil
+}
+
+func validateData(f entry.Data, value string) error {
+	switch f.Type {
+	case "choice":
+		for _, c := range f.ValueChoices {
+			if c == value {
+				return nil
+			}
+		}
+
+		return fmt.Errorf("%q is not one of %v", value, f.ValueChoices)
+	case "number":
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", value)
+		}
+
+		if f.MinValue != nil && n < *f.MinValue || f.MaxValue != nil && n > *f.MaxValue {
+			return fmt.Errorf("%q is out of range", value)
+		}
+	case "switch":
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("%q is not true or false", value)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"sync"
+
+	"github.com/marcokaiser/touchportal-golang-sdk/client"
+	"github.com/marcokaiser/touchportal-golang-sdk/entry"
+)
+
+var errNotPaired = errors.New("plugin disconnected before pairing")
+
+// namedValue is a single named setting or state value
+type namedValue struct {
+	Name  string
+	Value string
+}
+
+// actionData is the form in which TouchPortal sends the data fields of actions and connectors
+type actionData struct {
+	ID    string `json:"id"`
+	Value string `json:"value"`
+}
+
+type settingsMessage struct {
+	client.Message
+	Values []map[string]string `json:"values"`
+}
+
+// session is the simulated TouchPortal side of a single plugin connection
+type session struct {
+	entry *entry.Description
+	conn  net.Conn
+	out   io.Writer
+
+	reader *bufio.Reader
+
+	mu       sync.Mutex
+	settings []namedValue
+	states   map[string]string
+}
+
+func newSession(d *entry.Description, conn net.Conn, out io.Writer, overrides map[string]string) *session {
+	s := &session{
+		entry:  d,
+		conn:   conn,
+		out:    out,
+		reader: bufio.NewReader(conn),
+		states: make(map[string]string),
+	}
+
+	known := make(map[string]bool, len(d.Settings))
+	for _, ds := range d.Settings {
+		value := string(ds.Default)
+		if v, ok := overrides[ds.Name]; ok {
+			value = v
+		}
+
+		known[ds.Name] = true
+		s.settings = append(s.settings, namedValue{Name: ds.Name, Value: value})
+	}
+
+	for name, value := range overrides {
+		if !known[name] {
+			fmt.Fprintf(out, "warning: setting %q is not declared in entry.tp\n", name)
+			s.settings = append(s.settings, namedValue{Name: name, Value: value})
+		}
+	}
+
+	for _, c := range d.Categories {
+		for _, st := range c.States {
+			s.states[st.ID] = string(st.Default)
+		}
+	}
+
+	return s
+}
+
+// handshake waits for the plugin to send its pairing request and answers it with
+// the info message, including the current settings, just as TouchPortal does.
+func (s *session) handshake() error {
+	for {
+		line, err := s.reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			return errNotPaired
+		}
+
+		if err != nil {
+			return err
+		}
+
+		var m struct {
+			Type string `json:"type"`
+			ID   string `json:"id"`
+		}
+		if err := json.Unmarshal(line, &m); err != nil {
+			fmt.Fprintf(s.out, "unable to parse message from plugin: %v\n", err)
+			continue
+		}
+
+		if m.Type != client.MessageTypePair.String() {
+			fmt.Fprintf(s.out, "ignoring %q message received before pairing\n", m.Type)
+			continue
+		}
+
+		if m.ID != s.entry.ID {
+			fmt.Fprintf(s.out, "warning: plugin paired as %q but entry.tp declares %q\n", m.ID, s.entry.ID)
+		}
+
+		break
+	}
+
+	settings, err := json.Marshal(s.settingValues())
+	if err != nil {
+		return err
+	}
+
+	return s.send(client.InfoMessage{
+		Message:       client.Message{Type: client.MessageTypeInfo},
+		Version:       "tpsim",
+		SdkVersion:    s.entry.SDK,
+		PluginVersion: s.entry.Version,
+		Settings:      settings,
+	})
+}
+
+// readLoop reports every message the plugin sends and keeps track of state values.
+// It returns once the plugin disconnects.
+func (s *session) readLoop() error {
+	for {
+		line, err := s.reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		var m struct {
+			Type         string `json:"type"`
+			ID           string `json:"id"`
+			Value        string `json:"value"`
+			DefaultValue string `json:"defaultValue"`
+		}
+		if err := json.Unmarshal(line, &m); err != nil {
+			fmt.Fprintf(s.out, "unable to parse message from plugin: %v\n", err)
+			continue
+		}
+
+		switch m.Type {
+		case client.MessageTypeStateUpdate.String():
+			s.setState(m.ID, m.Value)
+			fmt.Fprintf(s.out, "state %s = %q\n", m.ID, m.Value)
+		case "createState":
+			s.setState(m.ID, m.DefaultValue)
+			fmt.Fprintf(s.out, "state %s created = %q\n", m.ID, m.DefaultValue)
+		case "removeState":
+			s.mu.Lock()
+			delete(s.states, m.ID)
+			s.mu.Unlock()
+			fmt.Fprintf(s.out, "state %s removed\n", m.ID)
+		default:
+			fmt.Fprintf(s.out, "received: %s", line)
+		}
+	}
+}
+
+func (s *session) send(m interface{}) error {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err = s.conn.Write(append(b, '\n'))
+
+	return err
+}
+
+func (s *session) sendAction(t client.ClientMessageType, actionID string, data []actionData) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	return s.send(client.ActionMessage{
+		Message:  client.Message{Type: t},
+		PluginID: s.entry.ID,
+		ActionID: actionID,
+		Data:     raw,
+	})
+}
+
+func (s *session) sendConnectorChange(connectorID string, value int, data []actionData) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	return s.send(client.ConnectorChangeMessage{
+		Message:     client.Message{Type: client.MessageTypeConnectorChange},
+		PluginID:    s.entry.ID,
+		ConnectorID: connectorID,
+		Value:       value,
+		Data:        raw,
+	})
+}
+
+func (s *session) sendClosePlugin() error {
+	return s.send(client.ClosePluginMessage{
+		Message:  client.Message{Type: client.MessageTypeClosePlugin},
+		PluginID: s.entry.ID,
+	})
+}
+
+// updateSetting changes a setting and notifies the plugin with the full set of values
+func (s *session) updateSetting(name string, value string) error {
+	s.mu.Lock()
+	found := false
+	for i := range s.settings {
+		if s.settings[i].Name == name {
+			s.settings[i].Value = value
+			found = true
+		}
+	}
+	s.mu.Unlock()
+
+	if !found {
+		return fmt.Errorf("unknown setting %q", name)
+	}
+
+	return s.send(settingsMessage{
+		Message: client.Message{Type: client.MessageTypeSettings},
+		Values:  s.settingValues(),
+	})
+}
+
+func (s *session) settingValues() []map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	values := make([]map[string]string, 0, len(s.settings))
+	for _, st := range s.settings {
+		values = append(values, map[string]string{st.Name: st.Value})
+	}
+
+	return values
+}
+
+func (s *session) setState(id string, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.states[id] = value
+}
+
+// stateValues returns the current state values sorted by state id
+func (s *session) stateValues() []namedValue {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	values := make([]namedValue, 0, len(s.states))
+	for id, v := range s.states {
+		values = append(values, namedValue{Name: id, Value: v})
+	}
+
+	sort.Slice(values, func(i, j int) bool {
+		return values[i].Name < values[j].Name
+	})
+
+	return values
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/marcokaiser/touchportal-golang-sdk/client"
+	"github.com/marcokaiser/touchportal-golang-sdk/entry"
+	"github.com/stretchr/testify/assert"
+)
+
+func testEntry() *entry.Description {
+	return &entry.Description{
+		SDK:     4,
+		Version: 2,
+		ID:      "gsdk",
+		Categories: []entry.Category{{
+			ID: "gsdk01",
+			Actions: []entry.Action{{
+				ID: "gsdk_mode",
+				Data: []entry.Data{{
+					ID:           "gsdk_mode_value",
+					Type:         "choice",
+					Default:      "on",
+					ValueChoices: []string{"on", "off"},
+				}},
+			}},
+			States: []entry.State{{ID: "gsdk_counter", Default: "0"}},
+		}},
+		Settings: []entry.Setting{
+			{Name: "Host", Default: "localhost"},
+			{Name: "Port", Default: "443"},
+		},
+	}
+}
+
+func TestSession_handshake(t *testing.T) {
+	t.Parallel()
+
+	simConn, pluginConn := net.Pipe()
+	defer simConn.Close()
+	defer pluginConn.Close()
+
+	s := newSession(testEntry(), simConn, io.Discard, map[string]string{"Port": "8080"})
+
+	done := make(chan error)
+	go func() {
+		done <- s.handshake()
+	}()
+
+	_, err := pluginConn.Write([]byte(`{"type":"pair","id":"gsdk"}` + "\n"))
+	assert.Nil(t, err)
+
+	line, err := bufio.NewReader(pluginConn).ReadBytes('\n')
+	assert.Nil(t, err)
+	assert.Nil(t, <-done)
+
+	var info client.InfoMessage
+	err = json.Unmarshal(line, &info)
+	assert.Nil(t, err, "info message should be readable by the client err: %v", err)
+	assert.Equal(t, client.MessageTypeInfo, info.Type)
+	assert.Equal(t, 4, info.SdkVersion)
+	assert.Equal(t, 2, info.PluginVersion)
+	assert.JSONEq(t, `[{"Host":"localhost"},{"Port":"8080"}]`, string(info.Settings))
+}
+
+func TestPrompt_action(t *testing.T) {
+	t.Parallel()
+
+	simConn, pluginConn := net.Pipe()
+	defer simConn.Close()
+	defer pluginConn.Close()
+
+	s := newSession(testEntry(), simConn, io.Discard, nil)
+	p := newPrompt(s, strings.NewReader("action gsdk_mode\nmaybe\noff\n"), io.Discard)
+
+	go p.run()
+
+	line, err := bufio.NewReader(pluginConn).ReadBytes('\n')
+	assert.Nil(t, err)
+
+	var action client.ActionMessage
+	err = json.Unmarshal(line, &action)
+	assert.Nil(t, err, "action message should be readable by the client err: %v", err)
+	assert.Equal(t, client.MessageTypeAction, action.Type)
+	assert.Equal(t, "gsdk", action.PluginID)
+	assert.Equal(t, "gsdk_mode", action.ActionID)
+	assert.JSONEq(t, `[{"id":"gsdk_mode_value","value":"off"}]`, string(action.Data),
+		"invalid choices should be asked for again")
+}
@@ -0,0 +1,138 @@
+package connector
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMapping(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		mapping Mapping
+		value   int
+		want    float64
+	}{
+		{name: "linear", mapping: Mapping{Min: 2700, Max: 6500}, value: 50, want: 4600},
+		{name: "linear reversed", mapping: Mapping{Min: 100, Max: 0}, value: 25, want: 75},
+		{name: "clamped below", mapping: Mapping{Min: 0, Max: 10}, value: -5, want: 0},
+		{name: "clamped above", mapping: Mapping{Min: 0, Max: 10}, value: 150, want: 10},
+		{name: "midi steps", mapping: Mapping{Min: 0, Max: 127, Step: 1}, value: 50, want: 64},
+		{name: "logarithmic", mapping: Mapping{Min: 0, Max: 1, Curve: Logarithmic}, value: 100, want: 1},
+		{name: "logarithmic middle", mapping: Mapping{Min: -60, Max: 0, Curve: Logarithmic, Step: 0.5}, value: 50, want: -15.5},
+		{name: "exponential middle", mapping: Mapping{Min: 0, Max: 99, Curve: Exponential, Step: 1}, value: 50, want: 24},
+		{name: "exponential start", mapping: Mapping{Min: 0, Max: 99, Curve: Exponential}, value: 0, want: 0},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.InDelta(t, tt.want, tt.mapping.Map(tt.value), 1e-9)
+		})
+	}
+}
+
+func TestMapping_Unmap(t *testing.T) {
+	t.Parallel()
+
+	mappings := []Mapping{
+		{Min: 2700, Max: 6500},
+		{Min: 100, Max: 0},
+		{Min: -60, Max: 0, Curve: Logarithmic},
+		{Min: 20, Max: 20000, Curve: Exponential, Base: 1000},
+	}
+
+	// every connector value survives the round trip, so feedback puts the slider back
+	// where the user left it
+	for _, m := range mappings {
+		for value := MinValue; value <= MaxValue; value++ {
+			assert.Equal(t, value, m.Unmap(m.Map(value)), "mapping %+v", m)
+		}
+	}
+
+	m := Mapping{Min: 0, Max: 10}
+	assert.Equal(t, 0, m.Unmap(-3))
+	assert.Equal(t, 100, m.Unmap(42))
+	assert.Equal(t, 0, Mapping{Min: 5, Max: 5}.Unmap(5))
+}
+
+func TestDeadband(t *testing.T) {
+	t.Parallel()
+
+	d := NewDeadband(3)
+
+	passed := []int{}
+	for _, v := range []int{50, 51, 49, 52, 53, 53, 98, 100, 100, 1, 0} {
+		if d.Pass(v) {
+			passed = append(passed, v)
+		}
+	}
+	assert.Equal(t, []int{50, 53, 98, 100, 1, 0}, passed)
+
+	d.Reset()
+	assert.True(t, d.Pass(0))
+}
+
+// recorder collects the values passed to it
+type recorder struct {
+	mu     sync.Mutex
+	values []int
+}
+
+func (r *recorder) record(v int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.values = append(r.values, v)
+}
+
+func (r *recorder) get() []int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]int(nil), r.values...)
+}
+
+func TestDebounce(t *testing.T) {
+	t.Parallel()
+
+	r := &recorder{}
+	fn := Debounce(20*time.Millisecond, r.record)
+
+	for v := 1; v <= 5; v++ {
+		fn(v)
+	}
+
+	assert.Eventually(t, func() bool {
+		return len(r.get()) > 0
+	}, time.Second, 5*time.Millisecond)
+
+	time.Sleep(40 * time.Millisecond)
+	assert.Equal(t, []int{5}, r.get())
+}
+
+func TestThrottle(t *testing.T) {
+	t.Parallel()
+
+	r := &recorder{}
+	fn := Throttle(30*time.Millisecond, r.record)
+
+	for v := 1; v <= 5; v++ {
+		fn(v)
+	}
+
+	// the first value passes straight away, the latest once the interval ends
+	assert.Equal(t, []int{1}, r.get())
+	assert.Eventually(t, func() bool {
+		return len(r.get()) == 2
+	}, time.Second, 5*time.Millisecond)
+
+	time.Sleep(60 * time.Millisecond)
+	assert.Equal(t, []int{1, 5}, r.get())
+}
@@ -0,0 +1,144 @@
+package connector
+
+import (
+	"sync"
+	"time"
+)
+
+// Deadband suppresses the small changes a connector sends whilst its slider jitters
+// around a position. It is safe for concurrent use.
+type Deadband struct {
+	width int
+
+	mu   sync.Mutex
+	last int
+	seen bool
+}
+
+// NewDeadband creates a Deadband passing only changes of at least width
+func NewDeadband(width int) *Deadband {
+	return &Deadband{width: width}
+}
+
+// Pass reports whether value has moved at least the width of the deadband from the last
+// value passed, remembering it if so. The first value, and the ends of the connector
+// range, always pass so the slider can be moved fully to either end.
+func (d *Deadband) Pass(value int) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.seen && value == d.last {
+		return false
+	}
+
+	diff := value - d.last
+	if diff < 0 {
+		diff = -diff
+	}
+
+	if d.seen && diff < d.width && value != MinValue && value != MaxValue {
+		return false
+	}
+
+	d.last = value
+	d.seen = true
+
+	return true
+}
+
+// Reset forgets the last value passed, so the next value always passes
+func (d *Deadband) Reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.seen = false
+}
+
+// Debounce returns a function that calls fn with the latest value it was given once it
+// has not been called for wait, so fn only sees where a slider came to rest.
+//
+// fn is called from a timer of its own, not from the handler it was passed to, so it is
+// not covered by the clients panic guard. A panic in fn ends the plugin whatever its
+// PanicPolicy, and fn must recover from any panic itself.
+func Debounce[T any](wait time.Duration, fn func(T)) func(T) {
+	var (
+		mu         sync.Mutex
+		timer      *time.Timer
+		generation int
+	)
+
+	return func(v T) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if timer != nil {
+			timer.Stop()
+		}
+
+		// a timer that has already fired may be waiting for the lock, the generation
+		// stops it calling fn with a value that has since been replaced
+		generation++
+		g := generation
+
+		timer = time.AfterFunc(wait, func() {
+			mu.Lock()
+			current := g == generation
+			mu.Unlock()
+
+			if current {
+				fn(v)
+			}
+		})
+	}
+}
+
+// Throttle returns a function that calls fn at most once every interval. The first value
+// is passed on straight away, later ones during the interval being held back so that only
+// the latest is passed on once it ends. The final position of a slider is never lost.
+//
+// Values held back are passed on from a timer of their own, which as with Debounce is not
+// covered by the clients panic guard, so fn must recover from any panic itself.
+func Throttle[T any](interval time.Duration, fn func(T)) func(T) {
+	var (
+		mu      sync.Mutex
+		last    time.Time
+		timer   *time.Timer
+		latest  T
+		pending bool
+	)
+
+	flush := func() {
+		mu.Lock()
+		v, ok := latest, pending
+		pending = false
+		timer = nil
+		last = time.Now()
+		mu.Unlock()
+
+		if ok {
+			fn(v)
+		}
+	}
+
+	return func(v T) {
+		mu.Lock()
+
+		since := time.Since(last)
+		if timer == nil && since >= interval {
+			last = time.Now()
+			mu.Unlock()
+
+			fn(v)
+
+			return
+		}
+
+		latest = v
+		pending = true
+		if timer == nil {
+			timer = time.AfterFunc(interval-since, flush)
+		}
+
+		mu.Unlock()
+	}
+}
@@ -0,0 +1,144 @@
+// Package connector turns the 0-100 values of TouchPortal connectors into the values a
+// plugin controls, and back again for the feedback sent with connectorUpdate.
+//
+//	volume := connector.Mapping{Min: -60, Max: 0, Curve: connector.Logarithmic, Step: 0.5}
+//	deadband := connector.NewDeadband(1)
+//
+//	p.OnConnectorChange(connector.Throttle(50*time.Millisecond, func(e client.ConnectorChangeMessage) {
+//	    if deadband.Pass(e.Value) {
+//	        mixer.SetVolume(volume.Map(e.Value))
+//	    }
+//	}), "gsdk_volume")
+//
+//	// later, when the volume is changed elsewhere
+//	_ = p.UpdateConnector("gsdk_volume", volume.Unmap(mixer.Volume()), nil)
+package connector
+
+import (
+	"math"
+)
+
+const (
+	// MinValue is the lowest value TouchPortal sends for a connector
+	MinValue = 0
+	// MaxValue is the highest value TouchPortal sends for a connector
+	MaxValue = 100
+)
+
+// DefaultBase is the base of the logarithmic and exponential curves when none is set
+const DefaultBase = 10
+
+// Curve is the shape of a Mapping, how the mapped value moves along its range as the
+// slider moves along its own
+type Curve int
+
+const (
+	// Linear moves the value evenly along the range
+	Linear Curve = iota
+	// Logarithmic moves the value quickly at the start of the range and slowly at the end,
+	// giving finer control of the top of the range, as is usual for volumes in dB
+	Logarithmic
+	// Exponential moves the value slowly at the start of the range and quickly at the end,
+	// giving finer control of the bottom of the range. It is the inverse of Logarithmic.
+	Exponential
+)
+
+// Mapping converts connector values, 0 to 100, to values between Min and Max and back.
+// Min may be greater than Max to reverse the direction of the slider.
+type Mapping struct {
+	Min   float64
+	Max   float64
+	Curve Curve
+	// Base sets how pronounced the Logarithmic and Exponential curves are, the larger the
+	// base the steeper the curve. It defaults to DefaultBase and must be greater than 1.
+	Base float64
+	// Step quantises mapped values to multiples of Step from Min, such as 1 for MIDI
+	// values. A zero step leaves values unquantised.
+	Step float64
+}
+
+// Map converts a connector value to one within the range of the mapping. Values outside
+// of 0 to 100 are clamped.
+func (m Mapping) Map(value int) float64 {
+	t := float64(clamp(value, MinValue, MaxValue)-MinValue) / (MaxValue - MinValue)
+
+	return m.quantise(m.Min + m.shape(t)*(m.Max-m.Min))
+}
+
+// Unmap converts a value within the range of the mapping back to the connector value that
+// would map to it, for sending with connectorUpdate. Values outside of the range are
+// clamped.
+func (m Mapping) Unmap(v float64) int {
+	if m.Max == m.Min {
+		return MinValue
+	}
+
+	t := (v - m.Min) / (m.Max - m.Min)
+	t = math.Max(0, math.Min(1, t))
+
+	return MinValue + int(math.Round(m.unshape(t)*(MaxValue-MinValue)))
+}
+
+// shape applies the curve to the position t, from 0 to 1, along the slider
+func (m Mapping) shape(t float64) float64 {
+	b := m.base()
+
+	switch m.Curve {
+	case Logarithmic:
+		return math.Log1p((b-1)*t) / math.Log(b)
+	case Exponential:
+		return (math.Pow(b, t) - 1) / (b - 1)
+	default:
+		return t
+	}
+}
+
+// unshape is the inverse of shape
+func (m Mapping) unshape(t float64) float64 {
+	b := m.base()
+
+	switch m.Curve {
+	case Logarithmic:
+		return (math.Pow(b, t) - 1) / (b - 1)
+	case Exponential:
+		return math.Log1p((b-1)*t) / math.Log(b)
+	default:
+		return t
+	}
+}
+
+func (m Mapping) base() float64 {
+	if m.Base <= 1 {
+		return DefaultBase
+	}
+
+	return m.Base
+}
+
+// quantise rounds v to the nearest step from Min, keeping it within the range
+func (m Mapping) quantise(v float64) float64 {
+	if m.Step <= 0 {
+		return v
+	}
+
+	v = m.Min + math.Round((v-m.Min)/m.Step)*m.Step
+
+	lo, hi := m.Min, m.Max
+	if lo > hi {
+		lo, hi = hi, lo
+	}
+
+	return math.Max(lo, math.Min(hi, v))
+}
+
+func clamp(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+
+	if v > hi {
+		return hi
+	}
+
+	return v
+}
@@ -0,0 +1,202 @@
+// Package entry describes the entry.tp file through which a plugin declares its actions,
+// states, events, connectors and settings to TouchPortal.
+package entry
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// Description is the top level of an entry.tp file
+type Description struct {
+	SDK             int           `json:"sdk"`
+	Version         int           `json:"version"`
+	Name            string        `json:"name"`
+	ID              string        `json:"id"`
+	Configuration   Configuration `json:"configuration"`
+	StartCmd        string        `json:"plugin_start_cmd,omitempty"`
+	StartCmdWindows string        `json:"plugin_start_cmd_windows,omitempty"`
+	StartCmdMac     string        `json:"plugin_start_cmd_mac,omitempty"`
+	StartCmdLinux   string        `json:"plugin_start_cmd_linux,omitempty"`
+	Categories      []Category    `json:"categories"`
+	Settings        []Setting     `json:"settings,omitempty"`
+}
+
+type Configuration struct {
+	ColorDark      string `json:"colorDark,omitempty"`
+	ColorLight     string `json:"colorLight,omitempty"`
+	ParentCategory string `json:"parentCategory,omitempty"`
+}
+
+type Category struct {
+	ID         string      `json:"id"`
+	Name       string      `json:"name"`
+	ImagePath  string      `json:"imagepath,omitempty"`
+	Actions    []Action    `json:"actions"`
+	Events     []Event     `json:"events"`
+	Connectors []Connector `json:"connectors,omitempty"`
+	States     []State     `json:"states"`
+}
+
+type Action struct {
+	ID                   string `json:"id"`
+	Name                 string `json:"name"`
+	Prefix               string `json:"prefix"`
+	Type                 string `json:"type"`
+	Description          string `json:"description,omitempty"`
+	Format               string `json:"format,omitempty"`
+	TryInline            Bool   `json:"tryInline,omitempty"`
+	HasHoldFunctionality Bool   `json:"hasHoldFunctionality,omitempty"`
+	Data                 []Data `json:"data,omitempty"`
+}
+
+type Connector struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Format string `json:"format,omitempty"`
+	Data   []Data `json:"data,omitempty"`
+}
+
+// Data describes a single field the user fills in when using an action or connector
+type Data struct {
+	ID            string   `json:"id"`
+	Type          string   `json:"type"`
+	Label         string   `json:"label,omitempty"`
+	Default       Value    `json:"default"`
+	ValueChoices  []string `json:"valueChoices,omitempty"`
+	MinValue      *float64 `json:"minValue,omitempty"`
+	MaxValue      *float64 `json:"maxValue,omitempty"`
+	AllowDecimals Bool     `json:"allowDecimals,omitempty"`
+}
+
+type Event struct {
+	ID           string   `json:"id"`
+	Name         string   `json:"name"`
+	Format       string   `json:"format"`
+	Type         string   `json:"type"`
+	ValueChoices []string `json:"valueChoices,omitempty"`
+	ValueType    string   `json:"valueType"`
+	ValueStateID string   `json:"valueStateId"`
+}
+
+type State struct {
+	ID           string   `json:"id"`
+	Type         string   `json:"type"`
+	Desc         string   `json:"desc"`
+	Default      Value    `json:"default"`
+	ValueChoices []string `json:"valueChoices,omitempty"`
+}
+
+type Setting struct {
+	Name       string   `json:"name"`
+	Type       string   `json:"type"`
+	Default    Value    `json:"default,omitempty"`
+	ReadOnly   Bool     `json:"readOnly,omitempty"`
+	IsPassword Bool     `json:"isPassword,omitempty"`
+	MaxLength  int      `json:"maxLength,omitempty"`
+	MinValue   *float64 `json:"minValue,omitempty"`
+	MaxValue   *float64 `json:"maxValue,omitempty"`
+}
+
+// Bool is a boolean that TouchPortal accepts either as a JSON boolean or as the
+// strings "true" and "false".
+type Bool bool
+
+// UnmarshalJSON implements the json.Unmarshaler interface for Bool
+func (b *Bool) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		v, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("unable to parse %q as a boolean: %w", s, err)
+		}
+
+		*b = Bool(v)
+
+		return nil
+	}
+
+	var v bool
+	if err := json.Unmarshal(data, &v); err != nil {
+		return fmt.Errorf("Bool should be a boolean or string, got %s", data)
+	}
+
+	*b = Bool(v)
+
+	return nil
+}
+
+// Value is a default value which may be written as a JSON string, number or boolean
+// and is always handled as a string, the same as TouchPortal does.
+type Value string
+
+// UnmarshalJSON implements the json.Unmarshaler interface for Value
+func (v *Value) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*v = Value(s)
+
+		return nil
+	}
+
+	var scalar interface{}
+	if err := json.Unmarshal(data, &scalar); err != nil {
+		return err
+	}
+
+	switch x := scalar.(type) {
+	case nil:
+		*v = ""
+	case float64:
+		*v = Value(strconv.FormatFloat(x, 'f', -1, 64))
+	case bool:
+		*v = Value(strconv.FormatBool(x))
+	default:
+		return fmt.Errorf("Value should be a string, number or boolean, got %s", data)
+	}
+
+	return nil
+}
+
+// Load reads and parses the entry.tp file at the given path
+func Load(path string) (*Description, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Description{}
+	if err := json.Unmarshal(b, d); err != nil {
+		return nil, fmt.Errorf("unable to parse %s: %w", path, err)
+	}
+
+	return d, nil
+}
+
+// Action finds the action with the given id in any of the categories
+func (d *Description) Action(id string) (Action, bool) {
+	for _, c := range d.Categories {
+		for _, a := range c.Actions {
+			if a.ID == id {
+				return a, true
+			}
+		}
+	}
+
+	return Action{}, false
+}
+
+// Connector finds the connector with the given id in any of the categories
+func (d *Description) Connector(id string) (Connector, bool) {
+	for _, c := range d.Categories {
+		for _, con := range c.Connectors {
+			if con.ID == id {
+				return con, true
+			}
+		}
+	}
+
+	return Connector{}, false
+}
@@ -36,7 +36,7 @@ func main() {
 	p.Settings(&settings{})
 
 	// registers our plugin with TouchPortal. Blocks until the plugin is ready for use
-	err := p.Register()
+	err := p.Register(ctx)
 	if err != nil {
 		fmt.Printf("Failed to register plugin with TouchPortal. %s", err)
 	}
@@ -53,7 +53,7 @@ func main() {
 	}, "gsdk_increment_counter")
 
 	// if you want an easy way to wait around for the plugin to exit plugin.Done() offers
-	// an unbuffered channel you can watch.
+	// a channel, closed when the plugin has finished, that you can watch.
 	<-p.Done()
 }
 
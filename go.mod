@@ -3,14 +3,18 @@ module github.com/marcokaiser/touchportal-golang-sdk
 go 1.20
 
 require (
+	github.com/eclipse/paho.mqtt.golang v1.4.3
 	github.com/golang/mock v1.6.0
 	github.com/stretchr/testify v1.8.2
-	go.acpr.dev/touchportal-golang-sdk v0.0.0-20210604182152-c6dd822d71f2
+	go.starlark.net v0.0.0-20231121155337-90ade8b19d09
 	golang.org/x/net v0.9.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/gorilla/websocket v1.5.0 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
+	golang.org/x/sync v0.2.0 // indirect
+	golang.org/x/sys v0.7.0 // indirect
 )
@@ -0,0 +1,15 @@
+// Package sliceutil holds helpers for the slices of handlers and hooks kept by the SDK
+package sliceutil
+
+// Without returns a copy of s with v removed. A new slice is built, rather than s being
+// changed in place, as a dispatch in progress may still be ranging over s.
+func Without[T comparable](s []T, v T) []T {
+	remaining := make([]T, 0, len(s))
+	for _, existing := range s {
+		if existing != v {
+			remaining = append(remaining, existing)
+		}
+	}
+
+	return remaining
+}
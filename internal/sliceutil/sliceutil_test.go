@@ -0,0 +1,18 @@
+package sliceutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithout(t *testing.T) {
+	t.Parallel()
+
+	a, b, c := new(int), new(int), new(int)
+	s := []*int{a, b, c}
+
+	assert.Equal(t, []*int{a, c}, Without(s, b))
+	assert.Equal(t, []*int{a, b, c}, s, "slice changed in place")
+	assert.Equal(t, []*int{a, b, c}, Without(s, new(int)))
+}
@@ -0,0 +1,282 @@
+// Package metrics keeps the measurements reported by clients in memory and exposes them
+// over HTTP in the Prometheus text exposition format.
+//
+//	reg := metrics.NewRegistry()
+//	p := plugin.NewPlugin(ctx, "gsdk", plugin.WithClientOptions(client.WithMetrics(reg)))
+//
+//	http.Handle("/metrics", reg)
+//	go http.ListenAndServe("127.0.0.1:9100", nil)
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/marcokaiser/touchportal-golang-sdk/client"
+)
+
+// DefaultBuckets are the upper bounds, in seconds, of the handler duration histogram
+var DefaultBuckets = []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5}
+
+const (
+	metricReceived        = "touchportal_messages_received_total"
+	metricSent            = "touchportal_messages_sent_total"
+	metricProcessorErrors = "touchportal_processor_errors_total"
+	metricUnhandled       = "touchportal_unhandled_messages_total"
+	metricHandlerPanics   = "touchportal_handler_panics_total"
+	metricHandlerDuration = "touchportal_handler_duration_seconds"
+	metricQueueDepth      = "touchportal_incoming_queue_depth"
+	metricRetries         = "touchportal_connection_retries_total"
+)
+
+var help = map[string]string{
+	metricReceived:        "Messages received from TouchPortal.",
+	metricSent:            "Messages sent to TouchPortal.",
+	metricProcessorErrors: "Messages that could not be processed into their type.",
+	metricUnhandled:       "Messages of a type with no processor.",
+	metricHandlerPanics:   "Panics recovered from message handlers.",
+	metricHandlerDuration: "Time taken by the handlers of a single message.",
+	metricQueueDepth:      "Messages waiting to be processed.",
+	metricRetries:         "Retries after failing to read from the TouchPortal socket.",
+}
+
+// labels uniquely identifies a series of a metric, formatted ready for exposition
+type labels string
+
+// labelEscaper escapes label values as the exposition format expects, which unlike a Go
+// string literal leaves anything other than backslashes, quotes and newlines as it is
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func newLabels(pairs ...string) labels {
+	parts := make([]string, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if pairs[i+1] == "" {
+			continue
+		}
+
+		parts = append(parts, pairs[i]+`="`+labelEscaper.Replace(pairs[i+1])+`"`)
+	}
+
+	return labels(strings.Join(parts, ","))
+}
+
+type histogram struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// Registry holds the measurements of any number of clients. It implements client.Metrics
+// for a single client and http.Handler to expose what it has collected.
+type Registry struct {
+	*instance
+
+	buckets []float64
+
+	mu         sync.Mutex
+	counters   map[string]map[labels]uint64
+	gauges     map[string]map[labels]float64
+	histograms map[string]map[labels]*histogram
+}
+
+// NewRegistry creates an empty Registry using DefaultBuckets for its histograms
+func NewRegistry() *Registry {
+	r := &Registry{
+		buckets:    DefaultBuckets,
+		counters:   make(map[string]map[labels]uint64),
+		gauges:     make(map[string]map[labels]float64),
+		histograms: make(map[string]map[labels]*histogram),
+	}
+	r.instance = &instance{registry: r}
+
+	return r
+}
+
+// Plugin returns a client.Metrics which records into the registry with an additional
+// plugin label. Use it to tell apart the clients of several plugins in the same process.
+func (r *Registry) Plugin(id string) client.Metrics {
+	return &instance{registry: r, plugin: id}
+}
+
+func (r *Registry) inc(name string, l labels) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.counters[name] == nil {
+		r.counters[name] = make(map[labels]uint64)
+	}
+	r.counters[name][l]++
+}
+
+func (r *Registry) set(name string, l labels, v float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.gauges[name] == nil {
+		r.gauges[name] = make(map[labels]float64)
+	}
+	r.gauges[name][l] = v
+}
+
+func (r *Registry) observe(name string, l labels, v float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.histograms[name] == nil {
+		r.histograms[name] = make(map[labels]*histogram)
+	}
+
+	h, ok := r.histograms[name][l]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(r.buckets))}
+		r.histograms[name][l] = h
+	}
+
+	for i, upper := range r.buckets {
+		if v <= upper {
+			h.counts[i]++
+		}
+	}
+	h.sum += v
+	h.count++
+}
+
+// ServeHTTP writes all collected metrics in the Prometheus text exposition format
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	_ = r.Write(w)
+}
+
+// Write writes all collected metrics to w in the Prometheus text exposition format
+func (r *Registry) Write(w io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b := &strings.Builder{}
+
+	for _, name := range sortedKeys(r.counters) {
+		writeHeader(b, name, "counter")
+		for _, l := range sortedLabels(r.counters[name]) {
+			fmt.Fprintf(b, "%s%s %d\n", name, braces(l), r.counters[name][l])
+		}
+	}
+
+	for _, name := range sortedKeys(r.gauges) {
+		writeHeader(b, name, "gauge")
+		for _, l := range sortedLabels(r.gauges[name]) {
+			fmt.Fprintf(b, "%s%s %s\n", name, braces(l), formatFloat(r.gauges[name][l]))
+		}
+	}
+
+	for _, name := range sortedKeys(r.histograms) {
+		writeHeader(b, name, "histogram")
+		for _, l := range sortedLabels(r.histograms[name]) {
+			h := r.histograms[name][l]
+			for i, upper := range r.buckets {
+				fmt.Fprintf(b, "%s_bucket%s %d\n", name, braces(join(l, "le", formatFloat(upper))), h.counts[i])
+			}
+			fmt.Fprintf(b, "%s_bucket%s %d\n", name, braces(join(l, "le", "+Inf")), h.count)
+			fmt.Fprintf(b, "%s_sum%s %s\n", name, braces(l), formatFloat(h.sum))
+			fmt.Fprintf(b, "%s_count%s %d\n", name, braces(l), h.count)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
+
+// instance records into a registry on behalf of a single client
+type instance struct {
+	registry *Registry
+	plugin   string
+}
+
+func (i *instance) MessageReceived(msgType string) {
+	i.registry.inc(metricReceived, newLabels("plugin", i.plugin, "type", msgType))
+}
+
+func (i *instance) MessageSent(msgType string) {
+	i.registry.inc(metricSent, newLabels("plugin", i.plugin, "type", msgType))
+}
+
+func (i *instance) ProcessorError(msgType string) {
+	i.registry.inc(metricProcessorErrors, newLabels("plugin", i.plugin, "type", msgType))
+}
+
+func (i *instance) UnhandledMessage(msgType string) {
+	i.registry.inc(metricUnhandled, newLabels("plugin", i.plugin, "type", msgType))
+}
+
+func (i *instance) HandlerPanic(msgType string) {
+	i.registry.inc(metricHandlerPanics, newLabels("plugin", i.plugin, "type", msgType))
+}
+
+func (i *instance) HandlerDuration(msgType string, d time.Duration) {
+	i.registry.observe(metricHandlerDuration, newLabels("plugin", i.plugin, "type", msgType), d.Seconds())
+}
+
+func (i *instance) QueueDepth(depth int) {
+	i.registry.set(metricQueueDepth, newLabels("plugin", i.plugin), float64(depth))
+}
+
+func (i *instance) ConnectionRetry() {
+	i.registry.inc(metricRetries, newLabels("plugin", i.plugin))
+}
+
+func writeHeader(b *strings.Builder, name string, kind string) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help[name], name, kind)
+}
+
+func braces(l labels) string {
+	if l == "" {
+		return ""
+	}
+
+	return "{" + string(l) + "}"
+}
+
+func join(l labels, key string, value string) labels {
+	extra := newLabels(key, value)
+	if l == "" {
+		return extra
+	}
+
+	return l + "," + extra
+}
+
+func formatFloat(f float64) string {
+	if math.IsInf(f, 1) {
+		return "+Inf"
+	}
+
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func sortedLabels[V any](m map[labels]V) []labels {
+	keys := make([]labels, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	return keys
+}
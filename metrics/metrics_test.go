@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry_ServeHTTP(t *testing.T) {
+	t.Parallel()
+
+	r := NewRegistry()
+	r.buckets = []float64{.01, .1}
+
+	r.MessageReceived("action")
+	r.MessageReceived("action")
+	r.Plugin("gsdk").MessageReceived("info")
+	r.MessageSent("stateUpdate")
+	r.UnhandledMessage("broadcast")
+	r.ConnectionRetry()
+	r.QueueDepth(3)
+	r.HandlerDuration("action", 5*time.Millisecond)
+	r.HandlerDuration("action", 50*time.Millisecond)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, `# HELP touchportal_connection_retries_total Retries after failing to read from the TouchPortal socket.
+# TYPE touchportal_connection_retries_total counter
+touchportal_connection_retries_total 1
+# HELP touchportal_messages_received_total Messages received from TouchPortal.
+# TYPE touchportal_messages_received_total counter
+touchportal_messages_received_total{plugin="gsdk",type="info"} 1
+touchportal_messages_received_total{type="action"} 2
+# HELP touchportal_messages_sent_total Messages sent to TouchPortal.
+# TYPE touchportal_messages_sent_total counter
+touchportal_messages_sent_total{type="stateUpdate"} 1
+# HELP touchportal_unhandled_messages_total Messages of a type with no processor.
+# TYPE touchportal_unhandled_messages_total counter
+touchportal_unhandled_messages_total{type="broadcast"} 1
+# HELP touchportal_incoming_queue_depth Messages waiting to be processed.
+# TYPE touchportal_incoming_queue_depth gauge
+touchportal_incoming_queue_depth 3
+# HELP touchportal_handler_duration_seconds Time taken by the handlers of a single message.
+# TYPE touchportal_handler_duration_seconds histogram
+touchportal_handler_duration_seconds_bucket{type="action",le="0.01"} 1
+touchportal_handler_duration_seconds_bucket{type="action",le="0.1"} 2
+touchportal_handler_duration_seconds_bucket{type="action",le="+Inf"} 2
+touchportal_handler_duration_seconds_sum{type="action"} 0.055
+touchportal_handler_duration_seconds_count{type="action"} 2
+`, w.Body.String())
+}
+
+func TestRegistry_ServeHTTP_labelValues(t *testing.T) {
+	t.Parallel()
+
+	r := NewRegistry()
+	r.Plugin("café\t\u00a0\"ünï\"\\\n").MessageSent("stateUpdate")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+
+	// only backslashes, quotes and newlines are escaped, the tab and non-breaking space
+	// being left as they are
+	assert.Contains(t, w.Body.String(), "touchportal_messages_sent_total{plugin=\"café\t\u00a0\\\"ünï\\\"\\\\\\n\",type=\"stateUpdate\"} 1")
+}
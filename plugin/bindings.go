@@ -0,0 +1,249 @@
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/marcokaiser/touchportal-golang-sdk/client"
+	"github.com/marcokaiser/touchportal-golang-sdk/internal/sliceutil"
+)
+
+// Observable is a value that tells others when it changes, such as a StateBinding, so
+// that states derived from it can be kept up to date.
+type Observable interface {
+	observe(fn func()) client.Unsubscribe
+}
+
+// StateBinding holds the value of a TouchPortal state, sending it to TouchPortal whenever it
+// changes. Values are formatted to the string TouchPortal expects, only being sent when
+// that string changes, and are sent again whenever the plugin (re)registers with TouchPortal.
+type StateBinding[T any] struct {
+	plugin *Plugin
+	id     string
+	format func(v T) string
+
+	mu      sync.Mutex
+	value   T
+	set     bool
+	sent    string
+	hasSent bool
+
+	observersMu sync.Mutex
+	observers   []*stateObserver
+}
+
+type stateObserver struct {
+	fn func()
+}
+
+// BindState binds a state of the plugin to a value of type T, formatted for TouchPortal by
+// format. A nil format uses fmt.Sprint.
+//
+//	counter := plugin.BindState[int](p, "gsdk_counter", nil)
+//	p.OnAction(func(event client.ActionMessage) {
+//	    _ = counter.Update(func(v int) int { return v + 1 })
+//	}, "gsdk_increment_counter")
+func BindState[T any](p *Plugin, id string, format func(v T) string) *StateBinding[T] {
+	if format == nil {
+		format = func(v T) string {
+			return fmt.Sprint(v)
+		}
+	}
+
+	s := &StateBinding[T]{plugin: p, id: id, format: format}
+	p.addBinding(s)
+
+	return s
+}
+
+// DeriveState binds a state of the plugin to a value computed from other bindings, the
+// inputs, computing and sending it straight away and again whenever any of them change.
+// Computations are run one at a time, so compute must not read the derived state itself.
+//
+//	status := plugin.DeriveState(p, "gsdk_status", nil, func() string {
+//	    return fmt.Sprintf("%s: %d%%", device.Get(), level.Get())
+//	}, device, level)
+func DeriveState[T any](p *Plugin, id string, format func(v T) string, compute func() T, inputs ...Observable) *StateBinding[T] {
+	s := BindState(p, id, format)
+
+	// computing within Update keeps an input changing concurrently from having an older
+	// result overwrite a newer one
+	update := func() {
+		if err := s.Update(func(T) T { return compute() }); err != nil {
+			p.log().Warn("unable to update derived state", "pluginId", p.ID, "stateId", id, "error", err)
+		}
+	}
+
+	for _, input := range inputs {
+		input.observe(update)
+	}
+	update()
+
+	return s
+}
+
+// ID returns the id of the bound state
+func (s *StateBinding[T]) ID() string {
+	return s.id
+}
+
+// Get returns the current value of the state
+func (s *StateBinding[T]) Get() T {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.value
+}
+
+// Set changes the value of the state, sending it to TouchPortal if its formatted value
+// differs from the one last sent.
+func (s *StateBinding[T]) Set(v T) error {
+	return s.Update(func(T) T {
+		return v
+	})
+}
+
+// Update changes the value of the state to the one returned by fn, which is passed the
+// current value, as Set does. Concurrent updates are applied one after another.
+func (s *StateBinding[T]) Update(fn func(v T) T) error {
+	s.mu.Lock()
+
+	s.value = fn(s.value)
+	s.set = true
+
+	formatted := s.format(s.value)
+	if s.hasSent && formatted == s.sent {
+		s.mu.Unlock()
+		return nil
+	}
+
+	err := s.send(formatted)
+	s.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	s.notify()
+
+	return nil
+}
+
+// send sends the formatted value to TouchPortal. It must be called with mu held.
+func (s *StateBinding[T]) send(formatted string) error {
+	if err := s.plugin.UpdateState(s.id, formatted); err != nil {
+		s.hasSent = false
+		return err
+	}
+
+	s.sent = formatted
+	s.hasSent = true
+
+	return nil
+}
+
+// resend sends the current value again, if one has been set
+func (s *StateBinding[T]) resend() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.set {
+		return nil
+	}
+
+	return s.send(s.format(s.value))
+}
+
+func (s *StateBinding[T]) observe(fn func()) client.Unsubscribe {
+	o := &stateObserver{fn: fn}
+
+	s.observersMu.Lock()
+	s.observers = append(s.observers, o)
+	s.observersMu.Unlock()
+
+	return client.Unsubscribe(func() {
+		s.observersMu.Lock()
+		defer s.observersMu.Unlock()
+
+		s.observers = sliceutil.Without(s.observers, o)
+	})
+}
+
+func (s *StateBinding[T]) notify() {
+	s.observersMu.Lock()
+	observers := s.observers
+	s.observersMu.Unlock()
+
+	for _, o := range observers {
+		o.fn()
+	}
+}
+
+// snapshot returns the current value, marshalled to JSON, if one has been set
+func (s *StateBinding[T]) snapshot() (json.RawMessage, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.set {
+		return nil, false
+	}
+
+	raw, err := json.Marshal(s.value)
+	if err != nil {
+		s.plugin.log().Warn("unable to marshal bound state", "pluginId", s.plugin.ID, "stateId", s.id, "error", err)
+		return nil, false
+	}
+
+	return raw, true
+}
+
+// restore sets the value from one returned by snapshot
+func (s *StateBinding[T]) restore(raw json.RawMessage) error {
+	var v T
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return err
+	}
+
+	return s.Set(v)
+}
+
+// boundState is a StateBinding of any type
+type boundState interface {
+	ID() string
+	resend() error
+	snapshot() (json.RawMessage, bool)
+	restore(raw json.RawMessage) error
+}
+
+// addBinding records the binding so it is sent again when the plugin registers with
+// TouchPortal, such as after reconnecting.
+func (p *Plugin) addBinding(s boundState) {
+	p.bindingsMu.Lock()
+
+	if p.bindings == nil {
+		p.OnInfo(func(event client.InfoMessage) {
+			p.resendBindings()
+		})
+	}
+
+	p.bindings = append(p.bindings, s)
+	snapshots := p.snapshots
+	p.bindingsMu.Unlock()
+
+	if snapshots != nil {
+		p.restoreState(snapshots, s)
+	}
+}
+
+func (p *Plugin) resendBindings() {
+	p.bindingsMu.Lock()
+	bindings := p.bindings
+	p.bindingsMu.Unlock()
+
+	for _, s := range bindings {
+		if err := s.resend(); err != nil {
+			p.log().Warn("unable to resend bound state", "pluginId", p.ID, "stateId", s.ID(), "error", err)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package plugin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/marcokaiser/touchportal-golang-sdk/client"
+	. "github.com/marcokaiser/touchportal-golang-sdk/plugin/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+// bindingClient returns a mock client recording the states sent and the info handler
+func bindingClient(ctrl *gomock.Controller, sent *[]string, info *func(e interface{})) *MockPluginClient {
+	mc := NewMockPluginClient(ctrl)
+	mc.EXPECT().AddMessageHandler(client.MessageTypeInfo, gomock.Any()).DoAndReturn(
+		func(_ client.ClientMessageType, handler func(e interface{})) client.Unsubscribe {
+			*info = handler
+			return func() {}
+		})
+	mc.EXPECT().SendMessage(gomock.Any()).DoAndReturn(func(m interface{}) error {
+		b, _ := json.Marshal(m)
+		*sent = append(*sent, string(b))
+		return nil
+	}).AnyTimes()
+
+	return mc
+}
+
+func TestBindState(t *testing.T) {
+	t.Parallel()
+
+	var sent []string
+	var info func(e interface{})
+	p := &Plugin{ID: "test", client: bindingClient(gomock.NewController(t), &sent, &info)}
+
+	counter := BindState(p, "counter", func(v int) string {
+		return fmt.Sprintf("%03d", v)
+	})
+
+	assert.NoError(t, counter.Set(1))
+	assert.NoError(t, counter.Set(1))
+	assert.NoError(t, counter.Update(func(v int) int {
+		return v + 1
+	}))
+	assert.Equal(t, 2, counter.Get())
+
+	// registering again, such as after reconnecting, sends the value again
+	info(client.InfoMessage{})
+
+	assert.Equal(t, []string{
+		`{"type":"stateUpdate","id":"counter","value":"001"}`,
+		`{"type":"stateUpdate","id":"counter","value":"002"}`,
+		`{"type":"stateUpdate","id":"counter","value":"002"}`,
+	}, sent)
+}
+
+func TestBindState_sendFailure(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	mc := NewMockPluginClient(ctrl)
+	mc.EXPECT().AddMessageHandler(client.MessageTypeInfo, gomock.Any())
+
+	p := &Plugin{ID: "test", client: mc}
+	s := BindState[string](p, "state", nil)
+
+	// a value that failed to send is sent again, even though it has not changed
+	gomock.InOrder(
+		mc.EXPECT().SendMessage(client.NewStateUpdateMessage("state", "on")).Return(errors.New("broken pipe")),
+		mc.EXPECT().SendMessage(client.NewStateUpdateMessage("state", "on")),
+	)
+
+	assert.Error(t, s.Set("on"))
+	assert.NoError(t, s.Set("on"))
+}
+
+func TestDeriveState(t *testing.T) {
+	t.Parallel()
+
+	var sent []string
+	var info func(e interface{})
+	p := &Plugin{ID: "test", client: bindingClient(gomock.NewController(t), &sent, &info)}
+
+	device := BindState[string](p, "device", nil)
+	level := BindState[int](p, "level", nil)
+
+	status := DeriveState(p, "status", nil, func() string {
+		return fmt.Sprintf("%s: %d%%", device.Get(), level.Get())
+	}, device, level)
+
+	assert.NoError(t, device.Set("mixer"))
+	assert.NoError(t, level.Set(50))
+	assert.NoError(t, level.Set(50))
+
+	assert.Equal(t, "mixer: 50%", status.Get())
+	assert.Equal(t, []string{
+		`{"type":"stateUpdate","id":"status","value":": 0%"}`,
+		`{"type":"stateUpdate","id":"device","value":"mixer"}`,
+		`{"type":"stateUpdate","id":"status","value":"mixer: 0%"}`,
+		`{"type":"stateUpdate","id":"level","value":"50"}`,
+		`{"type":"stateUpdate","id":"status","value":"mixer: 50%"}`,
+	}, sent)
+}
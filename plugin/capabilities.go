@@ -0,0 +1,65 @@
+package plugin
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrUnsupported is returned when sending a message that the connected TouchPortal, going
+// by the SDK version it reported when the plugin registered, does not support and would
+// ignore.
+var ErrUnsupported = errors.New("not supported by the connected touchportal")
+
+// Feature is a part of the TouchPortal api that is only available from a certain SDK version
+type Feature string
+
+const (
+	FeatureChoiceUpdate         Feature = "choiceUpdate"
+	FeatureChoiceUpdateInstance Feature = "choiceUpdateInstance"
+	FeatureConnectorUpdate      Feature = "connectorUpdate"
+	FeatureCreateState          Feature = "createState"
+	FeatureNotifications        Feature = "notifications"
+	FeatureRemoveState          Feature = "removeState"
+	FeatureSettingUpdate        Feature = "settingUpdate"
+	FeatureStateParentGroup     Feature = "stateParentGroup"
+	FeatureTriggerEvent         Feature = "triggerEvent"
+	FeatureUpdateActionData     Feature = "updateActionData"
+)
+
+// requiredSdkVersion is the SDK version each feature first appeared in
+var requiredSdkVersion = map[Feature]int{
+	FeatureChoiceUpdate:         2,
+	FeatureChoiceUpdateInstance: 2,
+	FeatureCreateState:          2,
+	FeatureRemoveState:          2,
+	FeatureSettingUpdate:        3,
+	FeatureUpdateActionData:     3,
+	FeatureConnectorUpdate:      4,
+	FeatureNotifications:        4,
+	FeatureStateParentGroup:     6,
+	FeatureTriggerEvent:         6,
+}
+
+// Supports reports whether the connected TouchPortal supports the feature. As the SDK
+// version is only known once TouchPortal has answered the pairing request, nothing is
+// supported before the plugin has registered.
+func (p *Plugin) Supports(f Feature) bool {
+	required, ok := requiredSdkVersion[f]
+
+	return ok && p.connectedSdkVersion() >= required
+}
+
+// require returns an ErrUnsupported naming the feature if it is not supported
+func (p *Plugin) require(f Feature) error {
+	if p.Supports(f) {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s requires sdk version %d, connected to %d", ErrUnsupported, f, requiredSdkVersion[f], p.connectedSdkVersion())
+}
+
+// connectedSdkVersion returns the SDK version TouchPortal reported when the plugin
+// registered, or 0 before it has
+func (p *Plugin) connectedSdkVersion() int {
+	return int(p.sdkVersion.Load())
+}
@@ -0,0 +1,299 @@
+package plugin
+
+import (
+	"sync"
+	"time"
+
+	"github.com/marcokaiser/touchportal-golang-sdk/client"
+	"github.com/marcokaiser/touchportal-golang-sdk/connector"
+)
+
+const (
+	// DefaultEchoWindow is how long after a connectorUpdate a matching connectorChange is
+	// taken to be its echo, when no other window is set using WithEchoWindow
+	DefaultEchoWindow = 500 * time.Millisecond
+
+	// DefaultHoldTimeout is how long after the last move of a slider the user is taken to
+	// still be holding it, when no other timeout is set using WithHoldTimeout
+	DefaultHoldTimeout = 300 * time.Millisecond
+)
+
+// ConflictPolicy decides between a user moving a slider and an external update of the
+// value it controls happening at the same time
+type ConflictPolicy int
+
+const (
+	// LastWriterWins applies whichever change happened last, an external update moving
+	// the slider even whilst the user holds it
+	LastWriterWins ConflictPolicy = iota
+	// UserWinsWhileHeld ignores external updates whilst the user holds the slider, the
+	// value the user leaves it at standing
+	UserWinsWhileHeld
+)
+
+// ConnectorBindingOption allows the configuration of a ConnectorBinding when calling
+// BindConnector
+type ConnectorBindingOption func(b *ConnectorBinding)
+
+// WithMapping sets how the 0-100 value of the connector maps to the bound value. By
+// default the bound value is the connector value.
+func WithMapping(m connector.Mapping) ConnectorBindingOption {
+	return func(b *ConnectorBinding) {
+		b.mapping = m
+	}
+}
+
+// WithEchoWindow sets how long after a connectorUpdate a matching connectorChange is taken
+// to be its echo, and ignored. It defaults to DefaultEchoWindow.
+func WithEchoWindow(d time.Duration) ConnectorBindingOption {
+	return func(b *ConnectorBinding) {
+		b.echoWindow = d
+	}
+}
+
+// WithHoldTimeout sets how long after the last move of the slider the user is taken to
+// still be holding it. It defaults to DefaultHoldTimeout.
+func WithHoldTimeout(d time.Duration) ConnectorBindingOption {
+	return func(b *ConnectorBinding) {
+		b.holdTimeout = d
+	}
+}
+
+// WithConflictPolicy sets how external updates made whilst the user holds the slider are
+// handled. It defaults to LastWriterWins.
+func WithConflictPolicy(policy ConflictPolicy) ConnectorBindingOption {
+	return func(b *ConnectorBinding) {
+		b.policy = policy
+	}
+}
+
+// WithConnectorData binds only the sliders of the connector with the given data, as
+// passed to UpdateConnector.
+func WithConnectorData(data map[string]string) ConnectorBindingOption {
+	return func(b *ConnectorBinding) {
+		b.data = data
+	}
+}
+
+// ConnectorBinding owns a value controlled by a connector, such as a volume, which may
+// also be changed elsewhere. Moves of the slider by the user are passed on, whilst changes
+// made elsewhere move the slider to match.
+//
+// TouchPortal may answer a connectorUpdate with a connectorChange of the same value,
+// which, passed on, could cause the change to bounce back and forth. Such echoes are
+// recognised and ignored.
+type ConnectorBinding struct {
+	plugin      *Plugin
+	id          string
+	data        map[string]string
+	onChange    func(v float64)
+	mapping     connector.Mapping
+	echoWindow  time.Duration
+	holdTimeout time.Duration
+	policy      ConflictPolicy
+	now         func() time.Time
+
+	mu          sync.Mutex
+	value       float64
+	set         bool
+	position    int
+	hasPosition bool
+	lastMove    time.Time
+	echoes      []echo
+
+	unsubs []client.Unsubscribe
+}
+
+// echo is a connector value sent to TouchPortal, which it may send back
+type echo struct {
+	value int
+	at    time.Time
+}
+
+// BindConnector binds a value to one of the plugins connectors, calling onChange with the
+// mapped value each time the user moves the slider.
+//
+//	volume := plugin.BindConnector(p, "gsdk_volume", mixer.SetVolume,
+//	    plugin.WithMapping(connector.Mapping{Min: -60, Max: 0, Curve: connector.Logarithmic}),
+//	    plugin.WithConflictPolicy(plugin.UserWinsWhileHeld))
+//
+//	mixer.OnVolumeChange(func(db float64) {
+//	    _ = volume.Set(db)
+//	})
+func BindConnector(p *Plugin, connectorID string, onChange func(v float64), opts ...ConnectorBindingOption) *ConnectorBinding {
+	b := &ConnectorBinding{
+		plugin:      p,
+		id:          connectorID,
+		onChange:    onChange,
+		mapping:     connector.Mapping{Min: connector.MinValue, Max: connector.MaxValue},
+		echoWindow:  DefaultEchoWindow,
+		holdTimeout: DefaultHoldTimeout,
+		now:         time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	b.unsubs = []client.Unsubscribe{
+		p.OnConnectorChange(b.handleChange, connectorID),
+		// registering again, such as after reconnecting, leaves the sliders where they
+		// were, so they are moved back to the value
+		p.onRegistered(func() {
+			if err := b.resend(); err != nil {
+				p.log().Warn("unable to resend connector value", "pluginId", p.ID, "connectorId", connectorID, "error", err)
+			}
+		}),
+	}
+
+	return b
+}
+
+// ID returns the id of the bound connector
+func (b *ConnectorBinding) ID() string {
+	return b.id
+}
+
+// Get returns the current value
+func (b *ConnectorBinding) Get() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.value
+}
+
+// Held reports whether the user is currently moving the slider
+func (b *ConnectorBinding) Held() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.held()
+}
+
+// Set changes the value from elsewhere, moving the slider to match. Under the
+// UserWinsWhileHeld policy the change is ignored whilst the user holds the slider,
+// Set returning false.
+func (b *ConnectorBinding) Set(v float64) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.policy == UserWinsWhileHeld && b.held() {
+		return false, nil
+	}
+
+	b.value = v
+	b.set = true
+
+	return true, b.send(b.mapping.Unmap(v))
+}
+
+// Unbind stops the binding following the connector
+func (b *ConnectorBinding) Unbind() {
+	for _, unsub := range b.unsubs {
+		unsub()
+	}
+}
+
+func (b *ConnectorBinding) handleChange(event client.ConnectorChangeMessage) {
+	if b.data != nil && !b.matches(event) {
+		return
+	}
+
+	b.mu.Lock()
+
+	now := b.now()
+	if b.isEcho(event.Value, now) {
+		b.mu.Unlock()
+		return
+	}
+
+	b.value = b.mapping.Map(event.Value)
+	b.set = true
+	b.position = event.Value
+	b.hasPosition = true
+	b.lastMove = now
+
+	v := b.value
+	b.mu.Unlock()
+
+	if b.onChange != nil {
+		b.onChange(v)
+	}
+}
+
+// matches reports whether the change is of a slider with the data of the binding
+func (b *ConnectorBinding) matches(event client.ConnectorChangeMessage) bool {
+	values, err := event.Values()
+	if err != nil {
+		return false
+	}
+
+	for k, v := range b.data {
+		if values[k] != v {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isEcho reports whether value was sent within the echo window, forgetting it if so as
+// TouchPortal only echoes it once. It must be called with mu held.
+func (b *ConnectorBinding) isEcho(value int, now time.Time) bool {
+	echoes := b.echoes[:0]
+	found := false
+
+	for _, e := range b.echoes {
+		if now.Sub(e.at) > b.echoWindow {
+			continue
+		}
+
+		if !found && e.value == value {
+			found = true
+			continue
+		}
+
+		echoes = append(echoes, e)
+	}
+	b.echoes = echoes
+
+	return found
+}
+
+// held reports whether the user has moved the slider within the hold timeout. It must be
+// called with mu held.
+func (b *ConnectorBinding) held() bool {
+	return !b.lastMove.IsZero() && b.now().Sub(b.lastMove) < b.holdTimeout
+}
+
+// send moves the slider to position, unless it is already there. It must be called with
+// mu held.
+func (b *ConnectorBinding) send(position int) error {
+	if b.hasPosition && position == b.position {
+		return nil
+	}
+
+	if err := b.plugin.UpdateConnector(b.id, position, b.data); err != nil {
+		return err
+	}
+
+	b.position = position
+	b.hasPosition = true
+	b.echoes = append(b.echoes, echo{value: position, at: b.now()})
+
+	return nil
+}
+
+// resend moves the slider to the current value again, if one has been set
+func (b *ConnectorBinding) resend() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.set {
+		return nil
+	}
+
+	b.hasPosition = false
+
+	return b.send(b.mapping.Unmap(b.value))
+}
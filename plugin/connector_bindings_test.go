@@ -0,0 +1,167 @@
+package plugin
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/marcokaiser/touchportal-golang-sdk/client"
+	"github.com/marcokaiser/touchportal-golang-sdk/connector"
+	. "github.com/marcokaiser/touchportal-golang-sdk/plugin/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+// connectorClient returns a mock client recording the connector updates sent, along with
+// the connectorChange handler
+func connectorClient(ctrl *gomock.Controller, sent *[]int, change *func(e interface{})) *MockPluginClient {
+	mc := NewMockPluginClient(ctrl)
+	mc.EXPECT().AddMessageHandler(client.MessageTypeConnectorChange, gomock.Any()).DoAndReturn(
+		func(_ client.ClientMessageType, handler func(e interface{})) client.Unsubscribe {
+			*change = handler
+			return func() {}
+		})
+	mc.EXPECT().SendMessage(gomock.Any()).DoAndReturn(func(m interface{}) error {
+		*sent = append(*sent, connectorValue(m))
+		return nil
+	}).AnyTimes()
+
+	return mc
+}
+
+func connectorValue(m interface{}) int {
+	var update struct {
+		Value int `json:"value"`
+	}
+	b, _ := json.Marshal(m)
+	_ = json.Unmarshal(b, &update)
+
+	return update.Value
+}
+
+func connectorChange(value int, data string) client.ConnectorChangeMessage {
+	return client.ConnectorChangeMessage{
+		Message:     client.Message{Type: client.MessageTypeConnectorChange},
+		PluginID:    "test",
+		ConnectorID: "volume",
+		Value:       value,
+		Data:        json.RawMessage(data),
+	}
+}
+
+func TestBindConnector(t *testing.T) {
+	t.Parallel()
+
+	var (
+		sent    []int
+		change  func(e interface{})
+		changes []float64
+	)
+	p := &Plugin{ID: "test", client: connectorClient(gomock.NewController(t), &sent, &change)}
+	p.sdkVersion.Store(4)
+
+	now := time.Unix(0, 0)
+	b := BindConnector(p, "volume", func(v float64) {
+		changes = append(changes, v)
+	}, WithMapping(connector.Mapping{Min: -50, Max: 0}))
+	b.now = func() time.Time { return now }
+
+	// the user moves the slider
+	change(connectorChange(50, `[]`))
+	assert.Equal(t, -25.0, b.Get())
+
+	// the value changes elsewhere, moving the slider, which touchportal echoes back
+	now = now.Add(time.Second)
+	ok, err := b.Set(-10)
+	assert.True(t, ok)
+	assert.NoError(t, err)
+	change(connectorChange(80, `[]`))
+
+	// the same value does not move the slider again
+	ok, err = b.Set(-10)
+	assert.True(t, ok)
+	assert.NoError(t, err)
+
+	// an echo arriving after the window is a move by the user
+	_, _ = b.Set(-5)
+	now = now.Add(time.Second)
+	change(connectorChange(90, `[]`))
+
+	// registering again moves the slider back to the value
+	p.infoReceivedHandler()(client.InfoMessage{SdkVersion: 4})
+
+	assert.Equal(t, []float64{-25, -5}, changes)
+	assert.Equal(t, []int{80, 90, 90}, sent)
+}
+
+func TestBindConnector_conflicts(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		policy   ConflictPolicy
+		wantSet  bool
+		wantSent []int
+		want     float64
+	}{
+		{name: "last writer wins", policy: LastWriterWins, wantSet: true, wantSent: []int{20}, want: 20},
+		{name: "user wins while held", policy: UserWinsWhileHeld, wantSet: false, wantSent: nil, want: 60},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				sent   []int
+				change func(e interface{})
+			)
+			p := &Plugin{ID: "test", client: connectorClient(gomock.NewController(t), &sent, &change)}
+			p.sdkVersion.Store(4)
+
+			now := time.Unix(0, 0)
+			b := BindConnector(p, "volume", nil, WithConflictPolicy(tt.policy))
+			b.now = func() time.Time { return now }
+
+			change(connectorChange(60, `[]`))
+			assert.True(t, b.Held())
+
+			now = now.Add(100 * time.Millisecond)
+			ok, err := b.Set(20)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantSet, ok)
+			assert.Equal(t, tt.want, b.Get())
+			assert.Equal(t, tt.wantSent, sent)
+
+			// once let go external updates apply under either policy
+			now = now.Add(time.Second)
+			assert.False(t, b.Held())
+
+			ok, _ = b.Set(30)
+			assert.True(t, ok)
+			assert.Equal(t, 30.0, b.Get())
+		})
+	}
+}
+
+func TestBindConnector_data(t *testing.T) {
+	t.Parallel()
+
+	var (
+		sent    []int
+		change  func(e interface{})
+		changes []float64
+	)
+	p := &Plugin{ID: "test", client: connectorClient(gomock.NewController(t), &sent, &change)}
+	p.sdkVersion.Store(4)
+
+	BindConnector(p, "volume", func(v float64) {
+		changes = append(changes, v)
+	}, WithConnectorData(map[string]string{"output": "speakers"}))
+
+	change(connectorChange(10, `[{"id":"output","value":"headphones"}]`))
+	change(connectorChange(20, `[{"id":"output","value":"speakers"}]`))
+
+	assert.Equal(t, []float64{20}, changes)
+}
@@ -0,0 +1,55 @@
+package plugin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSchedule(t *testing.T) {
+	t.Parallel()
+
+	// a wednesday
+	from := time.Date(2021, time.June, 2, 10, 7, 30, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		spec    string
+		want    time.Time
+		wantErr bool
+	}{
+		{name: "every minute", spec: "* * * * *", want: time.Date(2021, time.June, 2, 10, 8, 0, 0, time.UTC)},
+		{name: "step", spec: "*/15 * * * *", want: time.Date(2021, time.June, 2, 10, 15, 0, 0, time.UTC)},
+		{name: "list and range", spec: "0,30 9-11 * * *", want: time.Date(2021, time.June, 2, 10, 30, 0, 0, time.UTC)},
+		{name: "next day", spec: "0 9 * * *", want: time.Date(2021, time.June, 3, 9, 0, 0, 0, time.UTC)},
+		{name: "named weekdays", spec: "0 9 * * mon-tue", want: time.Date(2021, time.June, 7, 9, 0, 0, 0, time.UTC)},
+		{name: "sunday as seven", spec: "0 0 * * 7", want: time.Date(2021, time.June, 6, 0, 0, 0, 0, time.UTC)},
+		{name: "day of month or week", spec: "0 0 1 * fri", want: time.Date(2021, time.June, 4, 0, 0, 0, 0, time.UTC)},
+		{name: "named month", spec: "0 0 1 jan *", want: time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "descriptor", spec: "@hourly", want: time.Date(2021, time.June, 2, 11, 0, 0, 0, time.UTC)},
+		{name: "every", spec: "@every 90s", want: time.Date(2021, time.June, 2, 10, 9, 0, 0, time.UTC)},
+		{name: "never", spec: "0 0 30 feb *", want: time.Time{}},
+		{name: "too few fields", spec: "* * * *", wantErr: true},
+		{name: "out of range", spec: "60 * * * *", wantErr: true},
+		{name: "backwards range", spec: "* 5-1 * * *", wantErr: true},
+		{name: "bad step", spec: "*/0 * * * *", wantErr: true},
+		{name: "bad interval", spec: "@every soon", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			s, err := parseSchedule(tt.spec)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, s.next(from))
+		})
+	}
+}
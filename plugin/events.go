@@ -0,0 +1,247 @@
+package plugin
+
+import (
+	"context"
+	"sync"
+
+	"github.com/marcokaiser/touchportal-golang-sdk/client"
+)
+
+// Event is one of the messages TouchPortal sends to the plugin, as delivered by Events.
+// It is one of ActionEvent, HoldEvent, ConnectorEvent, SettingsEvent, ListChangeEvent,
+// BroadcastEvent or CloseEvent.
+type Event interface {
+	event()
+}
+
+// ActionEvent is sent when the user presses a button using one of the plugins actions
+type ActionEvent struct {
+	client.ActionMessage
+}
+
+// HoldEvent is sent when the user presses, and again when they release, a button using
+// one of the plugins actions that has hold functionality.
+type HoldEvent struct {
+	client.ActionMessage
+	Pressed bool
+}
+
+// ConnectorEvent is sent whilst the user moves a slider bound to one of the plugins connectors
+type ConnectorEvent struct {
+	client.ConnectorChangeMessage
+}
+
+// SettingsEvent is sent when the plugins settings are changed, Values holding the
+// settings by name.
+type SettingsEvent struct {
+	client.SettingsMessage
+}
+
+// ListChangeEvent is sent when the user changes the selected value of a choice list in
+// one of the plugins actions.
+type ListChangeEvent struct {
+	client.ListChangeMessage
+}
+
+// BroadcastEvent is sent to every plugin when something of general interest happens,
+// such as the user changing page.
+type BroadcastEvent struct {
+	client.BroadcastMessage
+}
+
+// CloseEvent is sent when TouchPortal asks the plugin to shut down
+type CloseEvent struct {
+	client.ClosePluginMessage
+}
+
+func (ActionEvent) event()     {}
+func (HoldEvent) event()       {}
+func (ConnectorEvent) event()  {}
+func (SettingsEvent) event()   {}
+func (ListChangeEvent) event() {}
+func (BroadcastEvent) event()  {}
+func (CloseEvent) event()      {}
+
+// OverflowPolicy decides what happens to an event when the channel returned by Events is full
+type OverflowPolicy int
+
+const (
+	// OverflowBlock waits for room in the channel, holding up the handling of any
+	// further messages until there is. This is the default.
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropNewest discards the event that does not fit in the channel
+	OverflowDropNewest
+	// OverflowDropOldest discards the oldest event in the channel to make room
+	OverflowDropOldest
+)
+
+// DefaultEventBuffer is the capacity of the channel returned by Events when no other is
+// set using WithEventBuffer.
+const DefaultEventBuffer = 16
+
+// EventsOption allows the configuration of the channel returned by Events
+type EventsOption func(s *eventStream)
+
+// WithEventBuffer sets the capacity of the channel returned by Events
+func WithEventBuffer(n int) EventsOption {
+	return func(s *eventStream) {
+		s.buffer = n
+	}
+}
+
+// WithEventOverflow sets what happens to events that do not fit in the channel returned
+// by Events. It defaults to OverflowBlock.
+func WithEventOverflow(policy OverflowPolicy) EventsOption {
+	return func(s *eventStream) {
+		s.overflow = policy
+	}
+}
+
+// Events provides the messages TouchPortal sends to the plugin as a channel of Event,
+// as an alternative to registering a handler for each. The events pass through the
+// same dispatch as those handlers, and any middleware, so both can be used together.
+//
+// The channel is closed once the context is done or the plugin has finished.
+//
+//	events := p.Events(ctx, plugin.WithEventOverflow(plugin.OverflowDropOldest))
+//	for e := range events {
+//	    switch e := e.(type) {
+//	    case plugin.ActionEvent:
+//	        // handle e.ActionID
+//	    case plugin.CloseEvent:
+//	        return
+//	    }
+//	}
+func (p *Plugin) Events(ctx context.Context, opts ...EventsOption) <-chan Event {
+	s := &eventStream{
+		buffer:  DefaultEventBuffer,
+		stopped: make(chan struct{}),
+		logger:  p.log(),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.events = make(chan Event, s.buffer)
+
+	unsubs := []client.Unsubscribe{
+		p.onActionMessage(client.MessageTypeAction, func(e client.ActionMessage) {
+			s.send(ActionEvent{e})
+		}),
+		p.onActionMessage(client.MessageTypeDown, func(e client.ActionMessage) {
+			s.send(HoldEvent{ActionMessage: e, Pressed: true})
+		}),
+		p.onActionMessage(client.MessageTypeUp, func(e client.ActionMessage) {
+			s.send(HoldEvent{ActionMessage: e})
+		}),
+		client.Handle(p.client, func(e client.ConnectorChangeMessage) {
+			if e.PluginID == p.ID {
+				s.send(ConnectorEvent{e})
+			}
+		}),
+		p.onSettings(func(e client.SettingsMessage) {
+			s.send(SettingsEvent{e})
+		}),
+		client.Handle(p.client, func(e client.ListChangeMessage) {
+			if e.PluginID == p.ID {
+				s.send(ListChangeEvent{e})
+			}
+		}),
+		client.Handle(p.client, func(e client.BroadcastMessage) {
+			s.send(BroadcastEvent{e})
+		}),
+		p.OnClosePlugin(func(e client.ClosePluginMessage) {
+			s.send(CloseEvent{e})
+		}),
+	}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-p.done:
+		}
+
+		for _, unsub := range unsubs {
+			unsub()
+		}
+		s.close()
+	}()
+
+	return s.events
+}
+
+// onActionMessage registers a handler for the action, down or up messages of the plugins
+// actions. Unlike OnAction it sees every action, regardless of the routes registered.
+func (p *Plugin) onActionMessage(msgType client.ClientMessageType, handler func(event client.ActionMessage)) client.Unsubscribe {
+	return client.HandleType(p.client, msgType, func(action client.ActionMessage) {
+		if action.PluginID == p.ID {
+			handler(action)
+		}
+	})
+}
+
+// eventStream delivers events to a channel according to an OverflowPolicy
+type eventStream struct {
+	buffer   int
+	overflow OverflowPolicy
+	logger   client.Logger
+
+	mu      sync.Mutex
+	closed  bool
+	stopped chan struct{}
+	events  chan Event
+}
+
+func (s *eventStream) send(e Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return
+	}
+
+	switch s.overflow {
+	case OverflowDropNewest:
+		select {
+		case s.events <- e:
+		default:
+			s.logger.Debug("event channel full, dropping newest event", "event", e)
+		}
+	case OverflowDropOldest:
+		select {
+		case s.events <- e:
+			return
+		default:
+		}
+
+		select {
+		case dropped := <-s.events:
+			s.logger.Debug("event channel full, dropping oldest event", "event", dropped)
+		default:
+		}
+
+		select {
+		case s.events <- e:
+		default:
+			// an unbuffered channel has no oldest event to drop
+			s.logger.Debug("event channel full, dropping newest event", "event", e)
+		}
+	default:
+		select {
+		case s.events <- e:
+		case <-s.stopped:
+		}
+	}
+}
+
+// close stops any blocked send before closing the channel, so no send can follow it
+func (s *eventStream) close() {
+	close(s.stopped)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	close(s.events)
+}
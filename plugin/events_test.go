@@ -0,0 +1,149 @@
+package plugin
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/marcokaiser/touchportal-golang-sdk/client"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlugin_Events(t *testing.T) {
+	t.Parallel()
+
+	c := client.NewClient()
+	p := &Plugin{ID: "testPlugin", client: c}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := p.Events(ctx)
+
+	// callback handlers continue to work alongside the channel
+	called := false
+	p.OnAction(func(event client.ActionMessage) {
+		called = true
+	}, "test")
+
+	c.Dispatch(client.MessageTypeAction, client.ActionMessage{PluginID: "testPlugin", ActionID: "test"})
+	c.Dispatch(client.MessageTypeAction, client.ActionMessage{PluginID: "otherPlugin", ActionID: "test"})
+	c.Dispatch(client.MessageTypeDown, client.ActionMessage{PluginID: "testPlugin", ActionID: "hold"})
+	c.Dispatch(client.MessageTypeUp, client.ActionMessage{PluginID: "testPlugin", ActionID: "hold"})
+	c.Dispatch(client.MessageTypeConnectorChange, client.ConnectorChangeMessage{PluginID: "testPlugin", Value: 50})
+	c.Dispatch(client.MessageTypeSettings, client.SettingsMessage{RawValues: []byte(`[{"Host":"localhost"}]`)})
+	c.Dispatch(client.MessageTypeListChange, client.ListChangeMessage{PluginID: "testPlugin", ListID: "list"})
+	c.Dispatch(client.MessageTypeBroadcast, client.BroadcastMessage{Event: "pageChange", PageName: "main"})
+	c.Dispatch(client.MessageTypeClosePlugin, client.ClosePluginMessage{PluginID: "testPlugin"})
+
+	cancel()
+
+	var got []Event
+	for e := range events {
+		got = append(got, e)
+	}
+
+	assert.True(t, called, "callback handler not called")
+	assert.Equal(t, []Event{
+		ActionEvent{client.ActionMessage{PluginID: "testPlugin", ActionID: "test"}},
+		HoldEvent{ActionMessage: client.ActionMessage{PluginID: "testPlugin", ActionID: "hold"}, Pressed: true},
+		HoldEvent{ActionMessage: client.ActionMessage{PluginID: "testPlugin", ActionID: "hold"}},
+		ConnectorEvent{client.ConnectorChangeMessage{PluginID: "testPlugin", Value: 50}},
+		SettingsEvent{client.SettingsMessage{
+			RawValues: []byte(`[{"Host":"localhost"}]`),
+			Values:    map[string]interface{}{"Host": "localhost"},
+		}},
+		ListChangeEvent{client.ListChangeMessage{PluginID: "testPlugin", ListID: "list"}},
+		BroadcastEvent{client.BroadcastMessage{Event: "pageChange", PageName: "main"}},
+		CloseEvent{client.ClosePluginMessage{PluginID: "testPlugin"}},
+	}, got)
+}
+
+func TestPlugin_Events_overflow(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		overflow OverflowPolicy
+		want     []string
+	}{
+		{
+			name:     "drop newest",
+			overflow: OverflowDropNewest,
+			want:     []string{"1", "2"},
+		},
+		{
+			name:     "drop oldest",
+			overflow: OverflowDropOldest,
+			want:     []string{"3", "4"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := client.NewClient()
+			p := &Plugin{ID: "testPlugin", client: c}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			events := p.Events(ctx, WithEventBuffer(2), WithEventOverflow(tt.overflow))
+
+			for _, id := range []string{"1", "2", "3", "4"} {
+				c.Dispatch(client.MessageTypeAction, client.ActionMessage{PluginID: "testPlugin", ActionID: id})
+			}
+
+			cancel()
+
+			var got []string
+			for e := range events {
+				got = append(got, e.(ActionEvent).ActionID)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestPlugin_Events_blockedSendReleasedOnDone(t *testing.T) {
+	t.Parallel()
+
+	c := client.NewClient()
+	p := &Plugin{ID: "testPlugin", client: c}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := p.Events(ctx, WithEventBuffer(0))
+
+	dispatched := make(chan bool)
+	go func() {
+		c.Dispatch(client.MessageTypeAction, client.ActionMessage{PluginID: "testPlugin", ActionID: "test"})
+		close(dispatched)
+	}()
+
+	cancel()
+	<-dispatched
+
+	for range events {
+	}
+}
+
+func TestPlugin_Events_closedOnDone(t *testing.T) {
+	t.Parallel()
+
+	p := &Plugin{ID: "testPlugin", client: client.NewClient(), done: make(chan bool)}
+
+	events := p.Events(context.Background())
+	close(p.done)
+
+	closed := make(chan bool)
+	go func() {
+		for range events {
+		}
+		close(closed)
+	}()
+
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("events channel not closed once the plugin finished")
+	}
+}
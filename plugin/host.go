@@ -0,0 +1,267 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/marcokaiser/touchportal-golang-sdk/client"
+)
+
+// ErrHostClosed is returned when adding a plugin to a Host that has already finished
+var ErrHostClosed = errors.New("host has finished")
+
+// ShutdownPolicy decides what a Host does when TouchPortal asks one of its plugins to close
+type ShutdownPolicy int
+
+const (
+	// ShutdownAll closes every plugin of the host when any one of them is asked to close.
+	// This is the default.
+	ShutdownAll ShutdownPolicy = iota
+	// ShutdownOne closes only the plugin that was asked to close, the host finishing once
+	// all of its plugins have.
+	ShutdownOne
+)
+
+// HostOption allows the configuration of a Host when calling NewHost
+type HostOption func(h *Host)
+
+// WithHostLogger sets the Logger shared by every plugin of the host
+func WithHostLogger(l client.Logger) HostOption {
+	return func(h *Host) {
+		h.logger = l
+	}
+}
+
+// WithHostMetrics sets the Metrics used by the client of each plugin, as returned by
+// metrics for the plugins id. A metrics.Registry can be shared by passing its Plugin method.
+func WithHostMetrics(metrics func(pluginID string) client.Metrics) HostOption {
+	return func(h *Host) {
+		h.metrics = metrics
+	}
+}
+
+// WithShutdownPolicy sets what the host does when one of its plugins is asked to close.
+// It defaults to ShutdownAll.
+func WithShutdownPolicy(policy ShutdownPolicy) HostOption {
+	return func(h *Host) {
+		h.policy = policy
+	}
+}
+
+// Host runs several plugins within a single process, each with its own connection to
+// TouchPortal, sharing a logger and metrics and shutting down together.
+//
+//	h := plugin.NewHost(ctx, plugin.WithHostLogger(logger))
+//	lights, _ := h.Add("lights")
+//	audio, _ := h.Add("audio")
+//	if err := h.Register(ctx); err != nil {
+//	    // handle error
+//	}
+//	<-h.Done()
+type Host struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	logger  client.Logger
+	metrics func(pluginID string) client.Metrics
+	policy  ShutdownPolicy
+
+	mu       sync.Mutex
+	plugins  []*Plugin
+	adding   map[string]bool
+	running  int
+	finished bool
+	done     chan bool
+}
+
+// NewHost creates a Host whose plugins run until the context is done or Close is called
+func NewHost(ctx context.Context, opts ...HostOption) *Host {
+	h := &Host{
+		logger: client.DefaultLogger(),
+		done:   make(chan bool),
+	}
+	h.ctx, h.cancel = context.WithCancel(ctx)
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
+}
+
+// Add creates a plugin with the given id, connecting it to TouchPortal, and adds it to the
+// host. Options are applied after those of the host, so may override them.
+func (h *Host) Add(id string, opts ...Option) (*Plugin, error) {
+	return h.add(nil, id, opts)
+}
+
+// AddWithClient adds a plugin using a custom client instance, as NewPluginWithClient does
+func (h *Host) AddWithClient(cli pluginClient, id string, opts ...Option) (*Plugin, error) {
+	return h.add(cli, id, opts)
+}
+
+func (h *Host) add(cli pluginClient, id string, opts []Option) (*Plugin, error) {
+	h.mu.Lock()
+
+	if h.finished {
+		h.mu.Unlock()
+		return nil, ErrHostClosed
+	}
+
+	if h.adding[id] || h.plugin(id) != nil {
+		h.mu.Unlock()
+		return nil, fmt.Errorf("plugin %q has already been added to the host", id)
+	}
+
+	// the id is reserved, and the plugin counted as running so the host cannot finish,
+	// whilst the plugin connects to TouchPortal without the lock held
+	if h.adding == nil {
+		h.adding = make(map[string]bool)
+	}
+	h.adding[id] = true
+	h.running++
+
+	h.mu.Unlock()
+
+	shared := []Option{WithLogger(h.logger)}
+	if h.metrics != nil {
+		shared = append(shared, WithClientOptions(client.WithMetrics(h.metrics(id))))
+	}
+
+	p := newPlugin(h.ctx, cli, id, append(shared, opts...))
+	p.OnClosePlugin(func(event client.ClosePluginMessage) {
+		if h.policy == ShutdownAll {
+			h.logger.Info("plugin asked to close, closing every plugin of the host", "pluginId", id)
+
+			go func() {
+				if err := h.Shutdown(context.Background()); err != nil {
+					h.logger.Warn("host did not shut down cleanly", "error", err)
+				}
+			}()
+		}
+	})
+
+	h.mu.Lock()
+	delete(h.adding, id)
+	h.plugins = append(h.plugins, p)
+	h.mu.Unlock()
+
+	go func() {
+		<-p.Done()
+		h.finish()
+	}()
+
+	return p, nil
+}
+
+// finish records that a plugin has finished, closing Done once they all have
+func (h *Host) finish() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.running--
+	if h.running == 0 {
+		h.finished = true
+		h.cancel()
+		close(h.done)
+	}
+}
+
+// Plugin returns the plugin of the host with the given id, or nil if there is none
+func (h *Host) Plugin(id string) *Plugin {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.plugin(id)
+}
+
+// plugin returns the plugin with the given id. It must be called with mu held.
+func (h *Host) plugin(id string) *Plugin {
+	for _, p := range h.plugins {
+		if p.ID == id {
+			return p
+		}
+	}
+
+	return nil
+}
+
+// Plugins returns the plugins of the host in the order they were added
+func (h *Host) Plugins() []*Plugin {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return append([]*Plugin(nil), h.plugins...)
+}
+
+// Register registers every plugin of the host with TouchPortal at the same time, returning
+// the errors of any that failed.
+func (h *Host) Register(ctx context.Context) error {
+	plugins := h.Plugins()
+	errs := make([]error, len(plugins))
+
+	wg := sync.WaitGroup{}
+	wg.Add(len(plugins))
+
+	for i, p := range plugins {
+		go func(i int, p *Plugin) {
+			defer wg.Done()
+
+			if err := p.Register(ctx); err != nil {
+				errs[i] = fmt.Errorf("plugin %q: %w", p.ID, err)
+			}
+		}(i, p)
+	}
+
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// Shutdown shuts every plugin of the host down at the same time, as Plugin.Shutdown does,
+// returning the errors of any that did not shut down cleanly.
+func (h *Host) Shutdown(ctx context.Context) error {
+	plugins := h.Plugins()
+	errs := make([]error, len(plugins))
+
+	wg := sync.WaitGroup{}
+	wg.Add(len(plugins))
+
+	for i, p := range plugins {
+		go func(i int, p *Plugin) {
+			defer wg.Done()
+
+			if err := p.Shutdown(ctx); err != nil {
+				errs[i] = fmt.Errorf("plugin %q: %w", p.ID, err)
+			}
+		}(i, p)
+	}
+
+	wg.Wait()
+	h.Close()
+
+	return errors.Join(errs...)
+}
+
+// Close asks every plugin of the host to stop. Done is closed once they have.
+func (h *Host) Close() {
+	h.cancel()
+
+	h.mu.Lock()
+	if h.running == 0 && !h.finished {
+		h.finished = true
+		close(h.done)
+	}
+	h.mu.Unlock()
+
+	for _, p := range h.Plugins() {
+		p.client.Close()
+	}
+}
+
+// Done provides a channel that is closed once every plugin of the host has finished
+func (h *Host) Done() <-chan bool {
+	return h.done
+}
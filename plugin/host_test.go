@@ -0,0 +1,154 @@
+package plugin
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/marcokaiser/touchportal-golang-sdk/client"
+	. "github.com/marcokaiser/touchportal-golang-sdk/plugin/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+const hostCapture = `{"time":"2021-06-04T18:00:00Z","message":{"type":"info","sdkVersion":6,"tpVersionString":"3.1.0"}}
+{"time":"2021-06-04T18:00:01Z","message":{"type":"closePlugin","pluginId":"closing"}}
+`
+
+// runningClient returns a mock client that runs until it is closed or its context is done
+func runningClient(ctrl *gomock.Controller) *MockPluginClient {
+	ready := make(chan bool)
+	close(ready)
+
+	mc, _ := connectingClient(ctrl, ready)
+
+	return mc
+}
+
+// connectingClient returns a mock client, as runningClient does, that is ready once ready
+// is closed, along with a channel closed once the plugin starts waiting for it
+func connectingClient(ctrl *gomock.Controller, ready chan bool) (*MockPluginClient, chan bool) {
+	waiting := make(chan bool)
+	waitingOnce := sync.Once{}
+
+	closed := make(chan bool)
+	closeOnce := sync.Once{}
+
+	mc := NewMockPluginClient(ctrl)
+	mc.EXPECT().Ready().DoAndReturn(func() <-chan bool {
+		waitingOnce.Do(func() {
+			close(waiting)
+		})
+
+		return ready
+	}).AnyTimes()
+	mc.EXPECT().Run(gomock.Any()).Do(func(ctx context.Context) {
+		select {
+		case <-ctx.Done():
+		case <-closed:
+		}
+	})
+	mc.EXPECT().AddMessageHandler(gomock.Any(), gomock.Any()).Return(client.Unsubscribe(func() {})).AnyTimes()
+	mc.EXPECT().StopReceiving().AnyTimes()
+	mc.EXPECT().Flush().AnyTimes()
+	mc.EXPECT().Close().Do(func() {
+		closeOnce.Do(func() {
+			close(closed)
+		})
+	}).AnyTimes()
+
+	return mc, waiting
+}
+
+func TestHost_shutdownPolicy(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		policy     ShutdownPolicy
+		wantClosed bool
+	}{
+		{name: "all", policy: ShutdownAll, wantClosed: true},
+		{name: "one", policy: ShutdownOne, wantClosed: false},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			ctrl := gomock.NewController(t)
+			h := NewHost(ctx, WithShutdownPolicy(tt.policy))
+
+			closing, err := h.AddWithClient(client.NewReplayClient(strings.NewReader(hostCapture), 0), "closing")
+			assert.NoError(t, err)
+
+			_, err = h.AddWithClient(runningClient(ctrl), "running")
+			assert.NoError(t, err)
+
+			_, err = h.AddWithClient(NewMockPluginClient(ctrl), "running")
+			assert.Error(t, err, "duplicate plugin id accepted")
+
+			assert.NoError(t, closing.Register(ctx))
+
+			select {
+			case <-h.Done():
+				assert.True(t, tt.wantClosed, "host finished though only one plugin was asked to close")
+			case <-time.After(100 * time.Millisecond):
+				assert.False(t, tt.wantClosed, "host did not finish when a plugin was asked to close")
+				h.Close()
+				<-h.Done()
+			}
+
+			_, err = h.AddWithClient(NewMockPluginClient(ctrl), "late")
+			assert.ErrorIs(t, err, ErrHostClosed)
+		})
+	}
+}
+
+func TestHost_Close_empty(t *testing.T) {
+	t.Parallel()
+
+	h := NewHost(context.Background())
+	h.Close()
+
+	select {
+	case <-h.Done():
+	case <-time.After(time.Second):
+		t.Fatal("empty host did not finish when closed")
+	}
+}
+
+func TestHost_add_connecting(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	h := NewHost(context.Background())
+
+	ready := make(chan bool)
+	mc, waiting := connectingClient(ctrl, ready)
+
+	added := make(chan error)
+	go func() {
+		_, err := h.AddWithClient(mc, "slow")
+		added <- err
+	}()
+	<-waiting
+
+	// whilst the plugin connects the host can still be used, its id staying taken
+	_, err := h.AddWithClient(NewMockPluginClient(ctrl), "slow")
+	assert.Error(t, err, "duplicate plugin id accepted")
+	assert.Empty(t, h.Plugins())
+
+	close(ready)
+	assert.NoError(t, <-added)
+	assert.NotNil(t, h.Plugin("slow"))
+
+	h.Close()
+	<-h.Done()
+}
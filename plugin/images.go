@@ -0,0 +1,56 @@
+package plugin
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+)
+
+// DefaultMaxImageSize is the largest image, in bytes once encoded, UpdateImageState sends
+// when no other limit is set using WithMaxImageSize
+const DefaultMaxImageSize = 256 * 1024
+
+// ErrImageTooLarge is returned by UpdateImageState for an image too large to send
+var ErrImageTooLarge = errors.New("image too large")
+
+// UpdateImageState sends an image as the value of an image state, encoded as the base64
+// PNG TouchPortal expects. Nothing is sent if the encoded image is the same as the one
+// last sent for the state, so images can be rendered on every change of the values they
+// show without flooding TouchPortal.
+func (p *Plugin) UpdateImageState(id string, img image.Image) error {
+	value, err := EncodeImage(img)
+	if err != nil {
+		return err
+	}
+
+	limit := p.maxImageSize
+	if limit == 0 {
+		limit = DefaultMaxImageSize
+	}
+
+	if len(value) > limit {
+		return fmt.Errorf("%w: image for state %q is %d bytes, limit is %d", ErrImageTooLarge, id, len(value), limit)
+	}
+
+	if last, ok := p.State(id); ok && last == value {
+		return nil
+	}
+
+	return p.UpdateState(id, value)
+}
+
+// EncodeImage encodes an image as the base64 PNG TouchPortal expects for the value of an
+// image state or icon.
+func EncodeImage(img image.Image) (string, error) {
+	var buf bytes.Buffer
+
+	enc := png.Encoder{CompressionLevel: png.BestCompression}
+	if err := enc.Encode(&buf, img); err != nil {
+		return "", fmt.Errorf("unable to encode image: %w", err)
+	}
+
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
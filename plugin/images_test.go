@@ -0,0 +1,46 @@
+package plugin
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/marcokaiser/touchportal-golang-sdk/plugin/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlugin_UpdateImageState(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	mc := NewMockPluginClient(ctrl)
+
+	p := &Plugin{ID: "test", client: mc}
+
+	red := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	red.Set(0, 0, color.RGBA{R: 0xff, A: 0xff})
+	blank := image.NewRGBA(image.Rect(0, 0, 8, 8))
+
+	// sending the same image again sends nothing
+	mc.EXPECT().SendMessage(gomock.Any()).Return(nil).Times(2)
+
+	assert.NoError(t, p.UpdateImageState("icon", red))
+	assert.NoError(t, p.UpdateImageState("icon", red))
+	assert.NoError(t, p.UpdateImageState("icon", blank))
+
+	value, _ := p.State("icon")
+	encoded, err := EncodeImage(blank)
+	assert.NoError(t, err)
+	assert.Equal(t, encoded, value)
+}
+
+func TestPlugin_UpdateImageState_tooLarge(t *testing.T) {
+	t.Parallel()
+
+	p := &Plugin{ID: "test", client: NewMockPluginClient(gomock.NewController(t))}
+	WithMaxImageSize(16)(p)
+
+	err := p.UpdateImageState("icon", image.NewRGBA(image.Rect(0, 0, 8, 8)))
+	assert.ErrorIs(t, err, ErrImageTooLarge)
+}
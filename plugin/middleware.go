@@ -0,0 +1,111 @@
+package plugin
+
+import (
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/marcokaiser/touchportal-golang-sdk/client"
+)
+
+// Handler handles a single message dispatched to the plugin
+type Handler = client.Handler
+
+// Middleware wraps the handling of every message dispatched to the plugin
+type Middleware = client.Middleware
+
+// Use adds middleware that wraps the handling of every message dispatched to the plugin,
+// regardless of its type. Middleware is run in the order it is added and is called once
+// per message, before any of the handlers registered for it.
+//
+//	p.Use(plugin.Recovery(reportError), plugin.Logging(logger))
+//	p.Use(func(next plugin.Handler) plugin.Handler {
+//	    return func(msgType client.ClientMessageType, event interface{}) {
+//	        // do something before the handlers run
+//	        next(msgType, event)
+//	    }
+//	})
+func (p *Plugin) Use(mw ...Middleware) {
+	p.client.Use(mw...)
+}
+
+// Logging logs every message dispatched to the plugin, along with how long it took to
+// handle, at debug level.
+func Logging(l client.Logger) Middleware {
+	return func(next Handler) Handler {
+		return func(msgType client.ClientMessageType, event interface{}) {
+			start := time.Now()
+			next(msgType, event)
+
+			args := append(eventAttributes(event), "type", msgType, "duration", time.Since(start))
+			l.Debug("handled message", args...)
+		}
+	}
+}
+
+// Recovery recovers from a panic further down the middleware chain, passing it to report
+// as a *client.PanicError. Panics in the registered handlers themselves are already
+// recovered by the client.
+func Recovery(report func(err error)) Middleware {
+	return func(next Handler) Handler {
+		return func(msgType client.ClientMessageType, event interface{}) {
+			defer func() {
+				if r := recover(); r != nil {
+					report(&client.PanicError{MessageType: msgType, Value: r, Stack: debug.Stack()})
+				}
+			}()
+
+			next(msgType, event)
+		}
+	}
+}
+
+// Timing reports how long each message took to pass through the rest of the chain
+func Timing(observe func(msgType client.ClientMessageType, d time.Duration)) Middleware {
+	return func(next Handler) Handler {
+		return func(msgType client.ClientMessageType, event interface{}) {
+			start := time.Now()
+			next(msgType, event)
+			observe(msgType, time.Since(start))
+		}
+	}
+}
+
+// RateLimit drops action messages that arrive within interval of the last one handled
+// for the same action. Each action is limited separately and other messages are passed
+// through untouched.
+func RateLimit(interval time.Duration) Middleware {
+	mu := sync.Mutex{}
+	last := make(map[string]time.Time)
+
+	return func(next Handler) Handler {
+		return func(msgType client.ClientMessageType, event interface{}) {
+			if action, ok := event.(client.ActionMessage); ok {
+				mu.Lock()
+				now := time.Now()
+				if t, seen := last[action.ActionID]; seen && now.Sub(t) < interval {
+					mu.Unlock()
+					return
+				}
+				last[action.ActionID] = now
+				mu.Unlock()
+			}
+
+			next(msgType, event)
+		}
+	}
+}
+
+// eventAttributes returns the identifying details of an event for structured logging
+func eventAttributes(event interface{}) []interface{} {
+	switch e := event.(type) {
+	case client.ActionMessage:
+		return []interface{}{"pluginId", e.PluginID, "actionId", e.ActionID}
+	case client.ConnectorChangeMessage:
+		return []interface{}{"pluginId", e.PluginID, "connectorId", e.ConnectorID, "value", e.Value}
+	case client.ClosePluginMessage:
+		return []interface{}{"pluginId", e.PluginID}
+	default:
+		return nil
+	}
+}
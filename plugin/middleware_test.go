@@ -0,0 +1,93 @@
+package plugin
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/marcokaiser/touchportal-golang-sdk/client"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlugin_Use(t *testing.T) {
+	t.Parallel()
+
+	p := &Plugin{
+		ID:     "test",
+		client: client.NewClient(),
+	}
+
+	var calls []string
+	record := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(msgType client.ClientMessageType, event interface{}) {
+				calls = append(calls, name+" before")
+				next(msgType, event)
+				calls = append(calls, name+" after")
+			}
+		}
+	}
+
+	p.Use(record("first"), record("second"))
+	p.OnAction(func(event client.ActionMessage) {
+		calls = append(calls, "a")
+	}, "a")
+	p.OnAction(func(event client.ActionMessage) {
+		calls = append(calls, "b")
+	}, "b")
+
+	p.client.Dispatch(client.MessageTypeAction, client.ActionMessage{PluginID: "test", ActionID: "a"})
+
+	assert.Equal(t, []string{"first before", "second before", "a", "second after", "first after"}, calls,
+		"middleware should wrap each message once, whatever the number of handlers")
+}
+
+func TestRateLimit(t *testing.T) {
+	t.Parallel()
+
+	calls := map[string]int{}
+	h := RateLimit(time.Hour)(func(msgType client.ClientMessageType, event interface{}) {
+		calls[event.(client.ActionMessage).ActionID]++
+	})
+
+	for i := 0; i < 3; i++ {
+		h(client.MessageTypeAction, client.ActionMessage{ActionID: "a"})
+		h(client.MessageTypeAction, client.ActionMessage{ActionID: "b"})
+	}
+
+	assert.Equal(t, map[string]int{"a": 1, "b": 1}, calls)
+}
+
+func TestRecovery(t *testing.T) {
+	t.Parallel()
+
+	var reported error
+	h := Recovery(func(err error) {
+		reported = err
+	})(func(msgType client.ClientMessageType, event interface{}) {
+		panic("boom")
+	})
+
+	h(client.MessageTypeInfo, client.InfoMessage{})
+
+	var pe *client.PanicError
+	if assert.True(t, errors.As(reported, &pe)) {
+		assert.Equal(t, client.MessageTypeInfo, pe.MessageType)
+		assert.Equal(t, "boom", pe.Value)
+	}
+}
+
+func TestTiming(t *testing.T) {
+	t.Parallel()
+
+	var observed time.Duration
+	h := Timing(func(msgType client.ClientMessageType, d time.Duration) {
+		observed = d
+	})(func(msgType client.ClientMessageType, event interface{}) {
+		time.Sleep(10 * time.Millisecond)
+	})
+
+	h(client.MessageTypeAction, client.ActionMessage{})
+
+	assert.GreaterOrEqual(t, observed, 10*time.Millisecond)
+}
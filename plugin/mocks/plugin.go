@@ -9,7 +9,7 @@ import (
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
-	client "go.acpr.dev/touchportal-golang-sdk/client"
+	client "github.com/marcokaiser/touchportal-golang-sdk/client"
 )
 
 // MockPluginClient is a mock of pluginClient interface.
@@ -36,9 +36,11 @@ func (m *MockPluginClient) EXPECT() *MockPluginClientMockRecorder {
 }
 
 // AddMessageHandler mocks base method.
-func (m *MockPluginClient) AddMessageHandler(arg0 client.ClientMessageType, arg1 func(interface{})) {
+func (m *MockPluginClient) AddMessageHandler(arg0 client.ClientMessageType, arg1 func(interface{})) client.Unsubscribe {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "AddMessageHandler", arg0, arg1)
+	ret := m.ctrl.Call(m, "AddMessageHandler", arg0, arg1)
+	ret0, _ := ret[0].(client.Unsubscribe)
+	return ret0
 }
 
 // AddMessageHandler indicates an expected call of AddMessageHandler.
@@ -71,6 +73,60 @@ func (mr *MockPluginClientMockRecorder) Dispatch(arg0, arg1 interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Dispatch", reflect.TypeOf((*MockPluginClient)(nil).Dispatch), arg0, arg1)
 }
 
+// Flush mocks base method.
+func (m *MockPluginClient) Flush() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Flush")
+}
+
+// Flush indicates an expected call of Flush.
+func (mr *MockPluginClientMockRecorder) Flush() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Flush", reflect.TypeOf((*MockPluginClient)(nil).Flush))
+}
+
+// Guard mocks base method.
+func (m *MockPluginClient) Guard(arg0 client.ClientMessageType, arg1 func(interface{})) func(interface{}) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Guard", arg0, arg1)
+	ret0, _ := ret[0].(func(interface{}))
+	return ret0
+}
+
+// Guard indicates an expected call of Guard.
+func (mr *MockPluginClientMockRecorder) Guard(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Guard", reflect.TypeOf((*MockPluginClient)(nil).Guard), arg0, arg1)
+}
+
+// OnAny mocks base method.
+func (m *MockPluginClient) OnAny(arg0 func(client.RawMessage)) client.Unsubscribe {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OnAny", arg0)
+	ret0, _ := ret[0].(client.Unsubscribe)
+	return ret0
+}
+
+// OnAny indicates an expected call of OnAny.
+func (mr *MockPluginClientMockRecorder) OnAny(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OnAny", reflect.TypeOf((*MockPluginClient)(nil).OnAny), arg0)
+}
+
+// OnError mocks base method.
+func (m *MockPluginClient) OnError(arg0 func(error)) client.Unsubscribe {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OnError", arg0)
+	ret0, _ := ret[0].(client.Unsubscribe)
+	return ret0
+}
+
+// OnError indicates an expected call of OnError.
+func (mr *MockPluginClientMockRecorder) OnError(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OnError", reflect.TypeOf((*MockPluginClient)(nil).OnError), arg0)
+}
+
 // Ready mocks base method.
 func (m *MockPluginClient) Ready() <-chan bool {
 	m.ctrl.T.Helper()
@@ -110,3 +166,31 @@ func (mr *MockPluginClientMockRecorder) SendMessage(arg0 interface{}) *gomock.Ca
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendMessage", reflect.TypeOf((*MockPluginClient)(nil).SendMessage), arg0)
 }
+
+// StopReceiving mocks base method.
+func (m *MockPluginClient) StopReceiving() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "StopReceiving")
+}
+
+// StopReceiving indicates an expected call of StopReceiving.
+func (mr *MockPluginClientMockRecorder) StopReceiving() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StopReceiving", reflect.TypeOf((*MockPluginClient)(nil).StopReceiving))
+}
+
+// Use mocks base method.
+func (m *MockPluginClient) Use(arg0 ...client.Middleware) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{}
+	for _, a := range arg0 {
+		varargs = append(varargs, a)
+	}
+	m.ctrl.Call(m, "Use", varargs...)
+}
+
+// Use indicates an expected call of Use.
+func (mr *MockPluginClientMockRecorder) Use(arg0 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Use", reflect.TypeOf((*MockPluginClient)(nil).Use), arg0...)
+}
@@ -0,0 +1,59 @@
+package plugin
+
+import (
+	"time"
+
+	"github.com/marcokaiser/touchportal-golang-sdk/client"
+)
+
+// Option allows the configuration of a Plugin when calling NewPlugin or NewPluginWithClient
+type Option func(p *Plugin)
+
+// WithLogger sets the Logger the plugin, and the client created by NewPlugin, report
+// their activity to. A *slog.Logger can be passed directly.
+func WithLogger(l client.Logger) Option {
+	return func(p *Plugin) {
+		p.logger = l
+	}
+}
+
+// WithClientOptions passes additional options to the client created by NewPlugin. They
+// are applied after the logger of the plugin, so may override it, and have no effect when
+// a client is provided using NewPluginWithClient.
+func WithClientOptions(opts ...client.Option) Option {
+	return func(p *Plugin) {
+		p.clientOptions = append(p.clientOptions, opts...)
+	}
+}
+
+// WithRegisterTimeout sets how long Register waits for TouchPortal to answer the pairing
+// request. It defaults to DefaultRegisterTimeout.
+func WithRegisterTimeout(d time.Duration) Option {
+	return func(p *Plugin) {
+		p.registerTimeout = d
+	}
+}
+
+// WithShutdownTimeout sets how long Shutdown waits for the plugin to shut down when its
+// context has no deadline. It defaults to DefaultShutdownTimeout.
+func WithShutdownTimeout(d time.Duration) Option {
+	return func(p *Plugin) {
+		p.shutdownTimeout = d
+	}
+}
+
+// WithResetStates has Shutdown set each of the given states, by id, back to its value
+// before the plugin disconnects.
+func WithResetStates(defaults map[string]string) Option {
+	return func(p *Plugin) {
+		p.stateDefaults = defaults
+	}
+}
+
+// WithMaxImageSize sets the largest image, in bytes once encoded, UpdateImageState sends.
+// It defaults to DefaultMaxImageSize.
+func WithMaxImageSize(n int) Option {
+	return func(p *Plugin) {
+		p.maxImageSize = n
+	}
+}
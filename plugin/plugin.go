@@ -3,21 +3,34 @@ package plugin
 
 import (
 	"context"
-	"log"
+	"fmt"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/marcokaiser/touchportal-golang-sdk/client"
+	"github.com/marcokaiser/touchportal-golang-sdk/internal/sliceutil"
 )
 
 type pluginClient interface {
-	AddMessageHandler(client.ClientMessageType, func(e interface{}))
+	AddMessageHandler(client.ClientMessageType, func(e interface{})) client.Unsubscribe
 	Close()
 	Dispatch(client.ClientMessageType, interface{})
+	Flush()
+	Guard(client.ClientMessageType, func(e interface{})) func(e interface{})
+	OnAny(func(msg client.RawMessage)) client.Unsubscribe
+	OnError(func(err error)) client.Unsubscribe
 	Ready() <-chan bool
 	Run(context.Context)
 	SendMessage(interface{}) error
+	StopReceiving()
+	Use(...client.Middleware)
 }
 
+// DefaultRegisterTimeout is how long Register waits for TouchPortal to answer the
+// pairing request when no other timeout is set using WithRegisterTimeout
+const DefaultRegisterTimeout = 30 * time.Second
+
 type Plugin struct {
 	ID                 string
 	TouchPortalVersion string
@@ -26,27 +39,71 @@ type Plugin struct {
 
 	settings interface{}
 
+	// sdkVersion is the SdkVersion read by Supports, which may be called from any
+	// goroutine whilst the plugin registers
+	sdkVersion atomic.Int32
+
+	router     *router
+	routerOnce sync.Once
+
+	jobs     *jobs
+	jobsOnce sync.Once
+
+	bindingsMu sync.Mutex
+	bindings   []boundState
+	snapshots  *Store
+
+	statesMu       sync.RWMutex
+	states         map[string]string
+	stateObservers []*stateUpdateObserver
+
+	logger          client.Logger
+	clientOptions   []client.Option
+	registerTimeout time.Duration
+	shutdownTimeout time.Duration
+	stateDefaults   map[string]string
+	maxImageSize    int
+
+	hooksMu         sync.Mutex
+	registeredHooks []*registeredHook
+	shutdownHooks   []*shutdownHook
+	shutdownOnce    sync.Once
+	shutdownErr     error
+
 	done   chan bool
 	client pluginClient
 }
 
 // NewPlugin creates, initialises and returns a TouchPortal plugin instance
-func NewPlugin(ctx context.Context, id string) *Plugin {
-	return NewPluginWithClient(ctx, client.NewClient(), id)
+func NewPlugin(ctx context.Context, id string, opts ...Option) *Plugin {
+	return newPlugin(ctx, nil, id, opts)
 }
 
 // NewPluginWithClient creates, initialises and returns a TouchPortal plugin instance allowing
 // the usage of a custom client instance
-func NewPluginWithClient(ctx context.Context, cli pluginClient, id string) *Plugin {
+func NewPluginWithClient(ctx context.Context, cli pluginClient, id string, opts ...Option) *Plugin {
+	return newPlugin(ctx, cli, id, opts)
+}
+
+func newPlugin(ctx context.Context, cli pluginClient, id string, opts []Option) *Plugin {
 	p := &Plugin{
 		ID:     id,
 		done:   make(chan bool),
-		client: cli,
+		logger: client.DefaultLogger(),
 	}
 
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	if cli == nil {
+		cli = client.NewClient(append([]client.Option{client.WithLogger(p.logger)}, p.clientOptions...)...)
+	}
+	p.client = cli
+
 	go func() {
 		p.client.Run(ctx)
-		p.done <- true
+		close(p.done)
 	}()
 
 	// wait until client is ready to be used
@@ -58,38 +115,86 @@ func NewPluginWithClient(ctx context.Context, cli pluginClient, id string) *Plug
 // Register asks the TouchPortal plugin instance to handle the registration process
 // with TouchPortal. It ensures that any settings are synced to the SDK and registers
 // a handler that allows the SDK to deal with shutdown requests.
-func (p *Plugin) Register() error {
-	wg := sync.WaitGroup{}
-	wg.Add(1)
-	p.OnInfo(p.infoReceivedHandler(&wg))
+//
+// Register blocks until TouchPortal has answered the pairing request. If it has not done
+// so by the time the context is done, or the registration timeout has passed, an error
+// wrapping the context error is returned.
+func (p *Plugin) Register(ctx context.Context) error {
+	timeout := p.registerTimeout
+	if timeout == 0 {
+		timeout = DefaultRegisterTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
 
-	p.OnClosePlugin(p.closePluginReceivedHandler())
+	// the info handler is registered first so the plugin is fully set up by the
+	// time the expectation is met
+	unsub := p.OnInfoOnce(p.infoReceivedHandler())
+	info := client.Expect(p.client, client.MessageTypeInfo, nil)
+
+	closeUnsub := p.OnClosePlugin(p.closePluginReceivedHandler())
 
 	err := p.client.SendMessage(client.NewPairMessage(p.ID))
 	if err != nil {
+		unsub()
+		closeUnsub()
+		info.Cancel()
+
 		return err
 	}
 
-	wg.Wait()
+	if _, err := info.Wait(ctx); err != nil {
+		unsub()
+		closeUnsub()
+
+		return fmt.Errorf("touchportal did not answer the pairing request: %w", err)
+	}
 
 	return nil
 }
 
+// Await waits for the next message of msgType for which predicate returns true, or
+// until the context is done. A nil predicate matches any message of the type.
+//
+//	e, err := p.Await(ctx, client.MessageTypeListChange, func(e interface{}) bool {
+//	    return e.(client.ListChangeMessage).ListID == "gsdk_device_list"
+//	})
+func (p *Plugin) Await(ctx context.Context, msgType client.ClientMessageType, predicate func(e interface{}) bool) (interface{}, error) {
+	return client.Expect(p.client, msgType, predicate).Wait(ctx)
+}
+
 // UpdateState allows you to send state update messages to TouchPortal
 func (p *Plugin) UpdateState(id string, value string) error {
 	msg := client.NewStateUpdateMessage(id, value)
 
-	return p.client.SendMessage(msg)
+	if err := p.client.SendMessage(msg); err != nil {
+		return err
+	}
+	p.trackState(id, value)
+
+	return nil
 }
 
-// Done provides an unbuffered, blocking, channel that can be used to verify
-// that the Plugin has finished it's run and cleaned up used resources.
+// Done provides a channel that is closed once the Plugin has finished it's run and cleaned
+// up used resources. Any number of goroutines may wait on it.
 func (p *Plugin) Done() <-chan bool {
 	return p.done
 }
 
-func (p *Plugin) infoReceivedHandler(wg *sync.WaitGroup) func(event client.InfoMessage) {
+func (p *Plugin) infoReceivedHandler() func(event client.InfoMessage) {
 	return func(event client.InfoMessage) {
+		p.TouchPortalVersion = event.Version
+		p.PluginVersion = event.PluginVersion
+		p.SdkVersion = event.SdkVersion
+		p.sdkVersion.Store(int32(event.SdkVersion))
+
+		p.log().Info("registered with touchportal",
+			"pluginId", p.ID,
+			"tpVersion", p.TouchPortalVersion,
+			"sdkVersion", p.SdkVersion,
+			"pluginVersion", p.PluginVersion)
+
 		if event.Settings != nil {
 			p.client.Dispatch(client.MessageTypeSettings, client.SettingsMessage{
 				Message:   client.Message{Type: client.MessageTypeSettings},
@@ -97,17 +202,64 @@ func (p *Plugin) infoReceivedHandler(wg *sync.WaitGroup) func(event client.InfoM
 			})
 		}
 
-		p.TouchPortalVersion = event.Version
-		p.PluginVersion = event.PluginVersion
-		p.SdkVersion = event.SdkVersion
+		p.hooksMu.Lock()
+		hooks := p.registeredHooks
+		p.hooksMu.Unlock()
 
-		wg.Done()
+		for _, hook := range hooks {
+			hook.fn()
+		}
 	}
 }
 
+type registeredHook struct {
+	fn func()
+}
+
+// onRegistered registers a hook that is run each time the plugin registers with
+// TouchPortal, once the versions it sent have been recorded. Unlike an OnInfo handler,
+// which may run before Register has seen the message, a hook can rely on them.
+func (p *Plugin) onRegistered(hook func()) client.Unsubscribe {
+	h := &registeredHook{fn: hook}
+
+	p.hooksMu.Lock()
+	p.registeredHooks = append(p.registeredHooks, h)
+	p.hooksMu.Unlock()
+
+	return client.Unsubscribe(func() {
+		p.hooksMu.Lock()
+		defer p.hooksMu.Unlock()
+
+		p.registeredHooks = sliceutil.Without(p.registeredHooks, h)
+	})
+}
+
 func (p *Plugin) closePluginReceivedHandler() func(event client.ClosePluginMessage) {
 	return func(event client.ClosePluginMessage) {
-		log.Println("touchportal requested plugin shutdown. quitting...")
-		p.client.Close()
+		p.log().Info("touchportal requested plugin shutdown. quitting...", "pluginId", p.ID)
+
+		// the shutdown waits for message handling to finish, which includes this handler
+		go func() {
+			if err := p.Shutdown(context.Background()); err != nil {
+				p.log().Warn("plugin did not shut down cleanly", "pluginId", p.ID, "error", err)
+			}
+		}()
+	}
+}
+
+// log returns the Logger for the plugin, falling back to the default when none is set
+func (p *Plugin) log() client.Logger {
+	if p.logger == nil {
+		return client.DefaultLogger()
 	}
+
+	return p.logger
+}
+
+// panicf logs the formatted message as an error before panicking with it, as log.Panicf would
+func (p *Plugin) panicf(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	p.log().Error(msg, "pluginId", p.ID)
+
+	panic(msg)
 }
@@ -1,8 +1,9 @@
 package plugin
 
 import (
+	"context"
 	"encoding/json"
-	"log"
+	"path"
 
 	"github.com/marcokaiser/touchportal-golang-sdk/client"
 )
@@ -17,52 +18,140 @@ const (
 	eventSettings
 )
 
-func (p *Plugin) on(event pluginEvent, handler func(event interface{})) {
+func (p *Plugin) on(event pluginEvent, handler func(event interface{})) client.Unsubscribe {
 	t, err := client.ClientMessageTypeString(event.String())
 	if err != nil {
-		log.Fatalf("unable to create event type, %v", err)
+		p.panicf("unable to create event type, %v", err)
 	}
 
-	p.client.AddMessageHandler(t, handler)
+	return p.client.AddMessageHandler(t, handler)
 }
 
 // OnAction allows the registration of an event handler to the "action" TouchPortal message.
 // The matching of the actionId parameter to the one sent by TouchPortal is handled for you
 // and your passed handler function will only be executed if it matches.
-func (p *Plugin) OnAction(handler func(event client.ActionMessage), actionID string) {
-	p.on(eventAction, p.onActionHandler(handler, actionID))
+//
+// The actionID may also be a pattern, using the syntax of path.Match, such as
+// "gsdk_device_*". Patterns are only tried for actions with no exact handler, the pattern
+// with the longest literal prefix winning when several match.
+//
+// Like all handler registrations it returns a client.Unsubscribe which removes the handler.
+func (p *Plugin) OnAction(handler func(event client.ActionMessage), actionID string) client.Unsubscribe {
+	guarded := p.client.Guard(client.MessageTypeAction, func(e interface{}) {
+		handler(e.(client.ActionMessage))
+	})
+
+	remove, err := p.actionRouter().add(actionID, func(event client.ActionMessage) {
+		guarded(event)
+	})
+	if err != nil {
+		p.panicf("invalid action route %q: %v", actionID, err)
+	}
+
+	return client.Unsubscribe(remove)
+}
+
+// OnActionOnce registers a handler, as OnAction does, that is removed after handling
+// a single action.
+func (p *Plugin) OnActionOnce(handler func(event client.ActionMessage), actionID string) client.Unsubscribe {
+	return client.Once(func(h func(event client.ActionMessage)) client.Unsubscribe {
+		return p.OnAction(h, actionID)
+	}, handler)
+}
+
+// OnConnectorChange allows the registration of an event handler to the "connectorChange"
+// TouchPortal message, sent whilst the user moves a slider bound to one of the plugins
+// connectors. The connectorID may be a pattern, using the syntax of path.Match.
+func (p *Plugin) OnConnectorChange(handler func(event client.ConnectorChangeMessage), connectorID string) client.Unsubscribe {
+	if _, err := path.Match(connectorID, ""); err != nil {
+		p.panicf("invalid connector pattern %q: %v", connectorID, err)
+	}
+
+	return client.Handle(p.client, func(event client.ConnectorChangeMessage) {
+		if event.PluginID != p.ID {
+			return
+		}
+
+		if ok, _ := path.Match(connectorID, event.ConnectorID); ok {
+			handler(event)
+		}
+	})
 }
 
 // OnClosePlugin allows the registration of an event handler to the "closePlugin" TouchPortal
 // message. A default handler is already in place to close down the plugin itself but you
 // may wish to add an additional hook so you can carry out other shutdown tasks.
-func (p *Plugin) OnClosePlugin(handler func(event client.ClosePluginMessage)) {
-	p.on(eventClosePlugin, p.onClosePluginHandler(handler))
+func (p *Plugin) OnClosePlugin(handler func(event client.ClosePluginMessage)) client.Unsubscribe {
+	return client.Handle(p.client, p.onClosePluginHandler(handler))
+}
+
+// OnClosePluginOnce registers a handler, as OnClosePlugin does, that is removed after
+// handling a single message.
+func (p *Plugin) OnClosePluginOnce(handler func(event client.ClosePluginMessage)) client.Unsubscribe {
+	return client.Once(p.OnClosePlugin, handler)
 }
 
 // OnInfo allows the registration of an event handler to the "info" TouchPortal message.
 // As the "info" message is only sent as a part of the registration process it is necessary
 // to register any custom handlers before plugin.Register function is called.
-func (p *Plugin) OnInfo(handler func(event client.InfoMessage)) {
-	p.on(eventInfo, func(e interface{}) {
-		handler(e.(client.InfoMessage))
-	})
+func (p *Plugin) OnInfo(handler func(event client.InfoMessage)) client.Unsubscribe {
+	return client.Handle(p.client, handler)
+}
+
+// OnInfoOnce registers a handler, as OnInfo does, that is removed after handling a
+// single message.
+func (p *Plugin) OnInfoOnce(handler func(event client.InfoMessage)) client.Unsubscribe {
+	return client.Once(p.OnInfo, handler)
+}
+
+// OnSettings allows the registration of an event handler to the plugins settings, sent by
+// TouchPortal when the plugin registers and again whenever the user changes them. The
+// values of the message are keyed by setting name. As with OnInfo, register the handler
+// before calling plugin.Register so the first settings are not missed.
+func (p *Plugin) OnSettings(handler func(event client.SettingsMessage)) client.Unsubscribe {
+	return p.onSettings(handler)
+}
+
+// OnUnknown allows the registration of an event handler to messages of a type the SDK does
+// not know about, such as those added by a newer version of TouchPortal. The message is
+// passed as it was received so you can make use of it before the SDK supports it.
+func (p *Plugin) OnUnknown(handler func(event client.UnknownMessage)) client.Unsubscribe {
+	return client.Handle(p.client, handler)
+}
+
+// OnAny allows the registration of a handler that is called with every message TouchPortal
+// sends, as it was received and before any other handler, for experimentation.
+func (p *Plugin) OnAny(handler func(msg client.RawMessage)) client.Unsubscribe {
+	return p.client.OnAny(handler)
+}
+
+// OnError allows the registration of a handler that is told about errors the SDK recovers
+// from whilst handling TouchPortal messages. A panic in any of your event handlers is
+// reported here as a *client.PanicError, which includes the stack trace of the panic.
+func (p *Plugin) OnError(handler func(err error)) client.Unsubscribe {
+	return p.client.OnError(handler)
+}
+
+// Bind removes the given handlers once the context is done, tying their lifetime to it.
+// As with client.UnsubscribeOnDone, the returned Unsubscribe removes them straight away and
+// should be called when the context may never be done.
+//
+//	p.Bind(ctx,
+//	    p.OnAction(start, "gsdk_start"),
+//	    p.OnAction(stop, "gsdk_stop"),
+//	)
+func (p *Plugin) Bind(ctx context.Context, unsubs ...client.Unsubscribe) client.Unsubscribe {
+	return client.UnsubscribeOnDone(ctx, unsubs...)
 }
 
 // onSettings sets up the necessary processing to turn a message containing settings
 // into a data structure that can be packed into a user supplied struct.
-func (p *Plugin) onSettings(handler func(event client.SettingsMessage)) {
-	p.on(eventSettings, func(e interface{}) {
-		msg, ok := e.(client.SettingsMessage)
-		if !ok {
-			log.Printf("failed to assert event is SettingsMessage: %+v", msg)
-			return
-		}
-
+func (p *Plugin) onSettings(handler func(event client.SettingsMessage)) client.Unsubscribe {
+	return client.Handle(p.client, func(msg client.SettingsMessage) {
 		settings := new([]map[string]interface{})
 		err := json.Unmarshal(msg.RawValues, settings)
 		if err != nil {
-			log.Printf("failed to unmarshal settings from raw data: %v", err)
+			p.log().Warn("failed to unmarshal settings from raw data", "pluginId", p.ID, "bytes", len(msg.RawValues), "error", err)
 			return
 		}
 
@@ -78,28 +167,34 @@ func (p *Plugin) onSettings(handler func(event client.SettingsMessage)) {
 	})
 }
 
-func (p *Plugin) onActionHandler(handler func(event client.ActionMessage), actionID string) func(e interface{}) {
+func (p *Plugin) onActionHandler() func(e interface{}) {
 	return func(e interface{}) {
 		action, ok := e.(client.ActionMessage)
 		if !ok {
 			return
 		}
 
-		if action.PluginID == p.ID && action.ActionID == actionID {
-			handler(action)
+		if action.PluginID != p.ID {
+			return
 		}
-	}
-}
 
-func (p *Plugin) onClosePluginHandler(handler func(event client.ClosePluginMessage)) func(e interface{}) {
-	return func(e interface{}) {
-		action, ok := e.(client.ClosePluginMessage)
-		if !ok {
+		handlers := p.router.match(action.ActionID)
+		if len(handlers) == 0 {
+			p.log().Debug("no route for action", "pluginId", action.PluginID, "actionId", action.ActionID)
 			return
 		}
 
-		if action.PluginID == p.ID {
-			handler(e.(client.ClosePluginMessage))
+		p.log().Debug("handling action", "pluginId", action.PluginID, "actionId", action.ActionID)
+		for _, handler := range handlers {
+			handler.fn(action)
+		}
+	}
+}
+
+func (p *Plugin) onClosePluginHandler(handler func(event client.ClosePluginMessage)) func(event client.ClosePluginMessage) {
+	return func(event client.ClosePluginMessage) {
+		if event.PluginID == p.ID {
+			handler(event)
 		}
 	}
 }
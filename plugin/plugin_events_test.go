@@ -58,10 +58,12 @@ func TestPlugin_onActionHandler(t *testing.T) {
 	actionID := "test"
 
 	p := &Plugin{
-		ID: "testPlugin",
+		ID:     "testPlugin",
+		client: client.NewClient(),
 	}
 
-	p.onActionHandler(handler, actionID)(msg)
+	p.OnAction(handler, actionID)
+	p.onActionHandler()(msg)
 
 	if !called {
 		t.Error("handler function not called despite good data")
@@ -68,21 +68,26 @@ func TestPlugin_Done(t *testing.T) {
 		done: make(chan bool),
 	}
 
-	wait := make(chan bool)
-	go func(wait chan bool) {
-		defer close(wait)
-
-		// the actual function call we're testing
-		<-p.Done()
-	}(wait)
+	// any number of goroutines may wait for the plugin to finish
+	waits := []chan bool{make(chan bool), make(chan bool)}
+	for _, wait := range waits {
+		go func(wait chan bool) {
+			defer close(wait)
+
+			// the actual function call we're testing
+			<-p.Done()
+		}(wait)
+	}
 
 	// something changes the stop status inside the plugin
-	p.done <- true
-
-	select {
-	case <-wait:
-	case <-time.After(100 * time.Millisecond):
-		t.Error("plugin not stopped before timeout")
+	close(p.done)
+
+	for _, wait := range waits {
+		select {
+		case <-wait:
+		case <-time.After(100 * time.Millisecond):
+			t.Error("plugin not stopped before timeout")
+		}
 	}
 }
 
@@ -139,7 +144,7 @@ func TestPlugin_Register(t *testing.T) {
 			go func() {
 				defer wg.Done()
 
-				err := p.Register()
+				err := p.Register(context.Background())
 				assert.Equal(t, tt.wantErr, (err != nil), "plugin register failed to match expected result")
 			}()
 			wg.Wait()
@@ -147,6 +152,35 @@ func TestPlugin_Register(t *testing.T) {
 	}
 }
 
+func TestPlugin_Register_timeout(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	mc := NewMockPluginClient(ctrl)
+
+	messageType, _ := client.ClientMessageTypeString("info")
+	mc.EXPECT().AddMessageHandler(messageType, gomock.Any()).Times(2).Return(client.Unsubscribe(func() {}))
+
+	closeUnsubscribed := false
+	messageType, _ = client.ClientMessageTypeString("closePlugin")
+	mc.EXPECT().AddMessageHandler(messageType, gomock.Any()).Return(client.Unsubscribe(func() {
+		closeUnsubscribed = true
+	}))
+
+	// touchportal never answers the pairing request
+	mc.EXPECT().SendMessage(client.NewPairMessage("test")).Return(nil)
+
+	p := &Plugin{
+		ID:              "test",
+		client:          mc,
+		registerTimeout: 10 * time.Millisecond,
+	}
+
+	err := p.Register(context.Background())
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.True(t, closeUnsubscribed, "closePlugin handler not removed after failed registration")
+}
+
 func TestPlugin_infoReceivedHandler(t *testing.T) {
 	t.Parallel()
 
@@ -165,9 +199,7 @@ func TestPlugin_infoReceivedHandler(t *testing.T) {
 		SdkVersion:    3,
 	}
 
-	wg := sync.WaitGroup{}
-	wg.Add(1)
-	sut := p.infoReceivedHandler(&wg)
+	sut := p.infoReceivedHandler()
 
 	sut(m)
 
@@ -215,9 +247,7 @@ func TestPlugin_infoReceivedHandler_withSettings(t *testing.T) {
 		Settings:      settings,
 	}
 
-	wg := sync.WaitGroup{}
-	wg.Add(1)
-	sut := p.infoReceivedHandler(&wg)
+	sut := p.infoReceivedHandler()
 
 	sut(m)
 }
@@ -228,13 +258,20 @@ func TestPlugin_closePluginHandler(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	mc := NewMockPluginClient(ctrl)
 
-	mc.EXPECT().Close()
-
 	p := &Plugin{
 		ID:     "test",
 		client: mc,
+		done:   make(chan bool),
 	}
 
+	gomock.InOrder(
+		mc.EXPECT().StopReceiving(),
+		mc.EXPECT().Flush(),
+		mc.EXPECT().Close().Do(func() {
+			close(p.done)
+		}),
+	)
+
 	m := client.ClosePluginMessage{
 		Message:  client.Message{Type: client.MessageTypeClosePlugin},
 		PluginID: "test",
@@ -243,19 +280,43 @@ func TestPlugin_closePluginHandler(t *testing.T) {
 	sut := p.closePluginReceivedHandler()
 
 	sut(m)
+
+	// the handler shuts down in the background, a second call waits for it to finish
+	assert.NoError(t, p.Shutdown(context.Background()))
 }
 
 func registrationFailureMocks(t *testing.T, id string) pluginClient {
 	ctrl := gomock.NewController(t)
 	mc := NewMockPluginClient(ctrl)
 
-	// register should setup info handler to set registration info
+	// register should setup info handlers to set registration info, removing them again
+	// when registration fails
+	unsubscribed := 0
 	messageType, _ := client.ClientMessageTypeString("info")
-	mc.EXPECT().AddMessageHandler(messageType, gomock.Any())
-
-	// register should add a closePlugin handler to handle shutdowns
+	mc.
+		EXPECT().
+		AddMessageHandler(messageType, gomock.Any()).
+		Times(2).
+		Return(client.Unsubscribe(func() {
+			unsubscribed++
+		}))
+	t.Cleanup(func() {
+		assert.Equal(t, 2, unsubscribed, "info handlers not removed after failed registration")
+	})
+
+	// register should add a closePlugin handler to handle shutdowns, removing it again
+	// when registration fails
+	closeUnsubscribed := false
 	messageType, _ = client.ClientMessageTypeString("closePlugin")
-	mc.EXPECT().AddMessageHandler(messageType, gomock.Any())
+	mc.
+		EXPECT().
+		AddMessageHandler(messageType, gomock.Any()).
+		Return(client.Unsubscribe(func() {
+			closeUnsubscribed = true
+		}))
+	t.Cleanup(func() {
+		assert.True(t, closeUnsubscribed, "closePlugin handler not removed after failed registration")
+	})
 
 	pairMessage := client.NewPairMessage(id)
 	mc.
@@ -272,7 +333,8 @@ func registrationSuccessMocks(t *testing.T, id string, version string, pluginVer
 
 	infoReceived := make(chan bool)
 
-	// register should setup info handler to set registration info
+	// register should setup info handlers to set registration info and to know
+	// when registration is complete
 	messageType, _ := client.ClientMessageTypeString("info")
 	mc.
 		EXPECT().
@@ -280,7 +342,8 @@ func registrationSuccessMocks(t *testing.T, id string, version string, pluginVer
 			messageType,
 			gomock.Any(),
 		).
-		DoAndReturn(func(msgType client.ClientMessageType, handler func(e interface{})) {
+		Times(2).
+		DoAndReturn(func(msgType client.ClientMessageType, handler func(e interface{})) client.Unsubscribe {
 			// by running in a goroutine and blocking on a channel that we later close
 			// we can mock the receipt of a message over the client socket
 			go func() {
@@ -295,6 +358,8 @@ func registrationSuccessMocks(t *testing.T, id string, version string, pluginVer
 
 				handler(m)
 			}()
+
+			return func() {}
 		})
 
 	// register should add a closePlugin handler to handle shutdowns
@@ -0,0 +1,85 @@
+// Package plugintest provides a plugin for testing code built on top of the plugin package,
+// without a TouchPortal to connect to.
+package plugintest
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/marcokaiser/touchportal-golang-sdk/client"
+	"github.com/marcokaiser/touchportal-golang-sdk/plugin"
+)
+
+// sentBuffer is the number of sent messages kept until they are read from Sent
+const sentBuffer = 64
+
+// Plugin is a plugin connected to a replay client, which receives only the messages it is
+// given through Receive.
+type Plugin struct {
+	*plugin.Plugin
+
+	// Client is the replay client of the plugin, through which messages can be dispatched
+	// directly to its handlers.
+	Client *client.Client
+
+	capture *client.CaptureWriter
+	sent    chan interface{}
+}
+
+// New returns a plugin with the given id for use in tests. It is stopped, and waited for,
+// once the test has finished.
+//
+//	p := plugintest.New(t, "test")
+//	p.OnAction(handler, "test_action")
+//	err := p.Receive(`{"type":"action","pluginId":"test","actionId":"test_action"}`)
+func New(t testing.TB, id string, opts ...plugin.Option) *Plugin {
+	t.Helper()
+
+	r, w := io.Pipe()
+
+	p := &Plugin{
+		Client:  client.NewReplayClient(r, 0),
+		capture: client.NewCaptureWriter(w),
+		sent:    make(chan interface{}, sentBuffer),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p.Plugin = plugin.NewPluginWithClient(ctx, &recorder{Client: p.Client, sent: p.sent}, id, opts...)
+
+	t.Cleanup(func() {
+		cancel()
+		w.Close()
+		<-p.Done()
+	})
+
+	return p
+}
+
+// Receive has the plugin receive the given message, as JSON, from TouchPortal. As with
+// TouchPortal nothing is received before the plugin has sent its pairing request, until
+// which Receive blocks.
+func (p *Plugin) Receive(msg string) error {
+	return p.capture.Write([]byte(msg))
+}
+
+// Sent returns the messages the plugin sends, in the order they are sent. Up to 64 are kept
+// until they are read, any sent while that many are waiting are dropped.
+func (p *Plugin) Sent() <-chan interface{} {
+	return p.sent
+}
+
+// recorder passes the messages the client sends on to sent
+type recorder struct {
+	*client.Client
+	sent chan interface{}
+}
+
+func (r *recorder) SendMessage(m interface{}) error {
+	select {
+	case r.sent <- m:
+	default:
+	}
+
+	return r.Client.SendMessage(m)
+}
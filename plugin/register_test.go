@@ -0,0 +1,50 @@
+package plugin_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/marcokaiser/touchportal-golang-sdk/plugin"
+	"github.com/marcokaiser/touchportal-golang-sdk/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBindConnector_beforeRegister(t *testing.T) {
+	t.Parallel()
+
+	p := plugintest.New(t, "test")
+
+	b := plugin.BindConnector(p.Plugin, "volume", nil)
+
+	// the value cannot be sent before the sdk version is known
+	_, err := b.Set(20)
+	assert.ErrorIs(t, err, plugin.ErrUnsupported)
+
+	go func() {
+		_ = p.Receive(`{"type":"info","sdkVersion":6}`)
+	}()
+	assert.NoError(t, p.Register(context.Background()))
+
+	// once registered it is sent along with the sdk version
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case m := <-p.Sent():
+			var update struct {
+				Type  string `json:"type"`
+				Value int    `json:"value"`
+			}
+			b, _ := json.Marshal(m)
+			_ = json.Unmarshal(b, &update)
+
+			if update.Type == "connectorUpdate" {
+				assert.Equal(t, 20, update.Value)
+				return
+			}
+		case <-timeout:
+			t.Fatal("connector value was not resent")
+		}
+	}
+}
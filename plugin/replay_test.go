@@ -0,0 +1,53 @@
+package plugin
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/marcokaiser/touchportal-golang-sdk/client"
+	"github.com/stretchr/testify/assert"
+)
+
+type replaySettings struct {
+	Host string `json:"Host"`
+	Port int    `json:"Port,string"`
+}
+
+func TestPlugin_replayedSession(t *testing.T) {
+	t.Parallel()
+
+	f, err := os.Open("testdata/session.jsonl")
+	if err != nil {
+		t.Fatalf("unable to open capture: %v", err)
+	}
+	defer f.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	p := NewPluginWithClient(ctx, client.NewReplayClient(f, 0), "gsdk")
+
+	s := &replaySettings{}
+	p.Settings(s)
+
+	counter := 0
+	p.OnAction(func(event client.ActionMessage) {
+		counter++
+	}, "gsdk_increment_counter")
+
+	err = p.Register(ctx)
+	assert.Nil(t, err, "failed to register plugin err: %v", err)
+
+	select {
+	case <-p.Done():
+	case <-ctx.Done():
+		t.Fatal("replay did not complete before timeout")
+	}
+
+	assert.Equal(t, "2.3.008", p.TouchPortalVersion)
+	assert.Equal(t, 3, p.SdkVersion)
+	assert.Equal(t, &replaySettings{Host: "localhost", Port: 443}, s)
+	assert.Equal(t, 2, counter, "only actions for this plugin should be handled")
+}
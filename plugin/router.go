@@ -0,0 +1,259 @@
+package plugin
+
+import (
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/marcokaiser/touchportal-golang-sdk/client"
+	"github.com/marcokaiser/touchportal-golang-sdk/internal/sliceutil"
+)
+
+// RouteKind describes how a route matches action ids
+type RouteKind string
+
+const (
+	RouteExact    RouteKind = "exact"
+	RoutePattern  RouteKind = "pattern"
+	RouteFallback RouteKind = "fallback"
+)
+
+// Route describes a registered action route, as returned by Plugin.Routes
+type Route struct {
+	Pattern  string
+	Kind     RouteKind
+	Handlers int
+}
+
+// routeHandler gives each registered handler an identity so it can be removed again
+type routeHandler struct {
+	fn func(event client.ActionMessage)
+}
+
+type patternRoute struct {
+	pattern  string
+	literal  int
+	handlers []*routeHandler
+}
+
+// router matches action messages to their handlers. Action ids registered as is are
+// found with a single map lookup. Only when no exact route exists are patterns tried,
+// most specific first, and then the fallback.
+type router struct {
+	mu       sync.RWMutex
+	exact    map[string][]*routeHandler
+	patterns []*patternRoute
+	fallback *routeHandler
+}
+
+func newRouter() *router {
+	return &router{
+		exact: make(map[string][]*routeHandler),
+	}
+}
+
+// isPattern reports whether the action id contains any of the path.Match meta characters
+func isPattern(id string) bool {
+	return strings.ContainsAny(id, `*?[\`)
+}
+
+// add registers the handler for the action id or pattern, returning a function that
+// removes it again.
+func (r *router) add(id string, handler func(event client.ActionMessage)) (func(), error) {
+	if isPattern(id) {
+		if _, err := path.Match(id, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	h := &routeHandler{fn: handler}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !isPattern(id) {
+		r.exact[id] = append(r.exact[id], h)
+
+		return func() { r.remove(id, h) }, nil
+	}
+
+	for _, pr := range r.patterns {
+		if pr.pattern == id {
+			pr.handlers = append(pr.handlers, h)
+
+			return func() { r.remove(id, h) }, nil
+		}
+	}
+
+	r.patterns = append(r.patterns, &patternRoute{
+		pattern:  id,
+		literal:  strings.IndexAny(id, `*?[\`),
+		handlers: []*routeHandler{h},
+	})
+
+	// the longer the literal prefix of a pattern the more specific it is
+	sort.SliceStable(r.patterns, func(i, j int) bool {
+		return r.patterns[i].literal > r.patterns[j].literal
+	})
+
+	return func() { r.remove(id, h) }, nil
+}
+
+// remove unregisters the handler, dropping the route entirely once it has no handlers.
+// New slices are built as a dispatch in progress may still be ranging over the old ones.
+func (r *router) remove(id string, h *routeHandler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !isPattern(id) {
+		handlers := sliceutil.Without(r.exact[id], h)
+		if len(handlers) == 0 {
+			delete(r.exact, id)
+			return
+		}
+
+		r.exact[id] = handlers
+
+		return
+	}
+
+	patterns := make([]*patternRoute, 0, len(r.patterns))
+	for _, pr := range r.patterns {
+		if pr.pattern == id {
+			pr = &patternRoute{pattern: pr.pattern, literal: pr.literal, handlers: sliceutil.Without(pr.handlers, h)}
+			if len(pr.handlers) == 0 {
+				continue
+			}
+		}
+
+		patterns = append(patterns, pr)
+	}
+
+	r.patterns = patterns
+}
+
+func (r *router) setFallback(handler func(event client.ActionMessage)) func() {
+	h := &routeHandler{fn: handler}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.fallback = h
+
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		if r.fallback == h {
+			r.fallback = nil
+		}
+	}
+}
+
+// match returns the handlers for the given action id
+func (r *router) match(id string) []*routeHandler {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if handlers, ok := r.exact[id]; ok {
+		return handlers
+	}
+
+	for _, pr := range r.patterns {
+		if ok, _ := path.Match(pr.pattern, id); ok {
+			return pr.handlers
+		}
+	}
+
+	if r.fallback != nil {
+		return []*routeHandler{r.fallback}
+	}
+
+	return nil
+}
+
+func (r *router) routes() []Route {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	routes := make([]Route, 0, len(r.exact)+len(r.patterns)+1)
+	for id, handlers := range r.exact {
+		routes = append(routes, Route{Pattern: id, Kind: RouteExact, Handlers: len(handlers)})
+	}
+
+	sort.Slice(routes, func(i, j int) bool {
+		return routes[i].Pattern < routes[j].Pattern
+	})
+
+	for _, pr := range r.patterns {
+		routes = append(routes, Route{Pattern: pr.pattern, Kind: RoutePattern, Handlers: len(pr.handlers)})
+	}
+
+	if r.fallback != nil {
+		routes = append(routes, Route{Pattern: "*", Kind: RouteFallback, Handlers: 1})
+	}
+
+	return routes
+}
+
+// RouteGroup registers action handlers under a common action id prefix, typically the
+// id of the category the actions belong to in entry.tp.
+type RouteGroup struct {
+	plugin *Plugin
+	prefix string
+}
+
+// Group creates a RouteGroup for actions whose ids start with prefix
+//
+//	devices := p.Group("gsdk_device_")
+//	devices.OnAction(setVolume, "volume") // handles "gsdk_device_volume"
+func (p *Plugin) Group(prefix string) *RouteGroup {
+	return &RouteGroup{plugin: p, prefix: prefix}
+}
+
+// OnAction registers a handler for the action with the groups prefix followed by actionID
+func (g *RouteGroup) OnAction(handler func(event client.ActionMessage), actionID string) client.Unsubscribe {
+	return g.plugin.OnAction(handler, g.prefix+actionID)
+}
+
+// Group creates a nested RouteGroup whose prefix follows on from this groups prefix
+func (g *RouteGroup) Group(prefix string) *RouteGroup {
+	return &RouteGroup{plugin: g.plugin, prefix: g.prefix + prefix}
+}
+
+// Fallback registers a handler for any action within the group that has no handler of its own
+func (g *RouteGroup) Fallback(handler func(event client.ActionMessage)) client.Unsubscribe {
+	return g.plugin.OnAction(handler, g.prefix+"*")
+}
+
+// OnUnmatchedAction registers a handler for actions that no other route matches. Only one
+// fallback exists, registering another replaces it.
+func (p *Plugin) OnUnmatchedAction(handler func(event client.ActionMessage)) client.Unsubscribe {
+	guarded := p.client.Guard(client.MessageTypeAction, func(e interface{}) {
+		handler(e.(client.ActionMessage))
+	})
+
+	remove := p.actionRouter().setFallback(func(event client.ActionMessage) {
+		guarded(event)
+	})
+
+	return client.Unsubscribe(remove)
+}
+
+// Routes lists the registered action routes for diagnostics. Exact routes are listed by
+// action id, followed by patterns in the order they are tried and finally the fallback.
+func (p *Plugin) Routes() []Route {
+	return p.actionRouter().routes()
+}
+
+// actionRouter returns the plugins router, creating it and registering it to receive
+// action messages the first time it is needed.
+func (p *Plugin) actionRouter() *router {
+	p.routerOnce.Do(func() {
+		p.router = newRouter()
+		p.on(eventAction, p.onActionHandler())
+	})
+
+	return p.router
+}
@@ -0,0 +1,105 @@
+package plugin
+
+import (
+	"testing"
+
+	"github.com/marcokaiser/touchportal-golang-sdk/client"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlugin_actionRouting(t *testing.T) {
+	t.Parallel()
+
+	p := &Plugin{
+		ID:     "gsdk",
+		client: client.NewClient(),
+	}
+
+	var handled []string
+	record := func(name string) func(event client.ActionMessage) {
+		return func(event client.ActionMessage) {
+			handled = append(handled, name+":"+event.ActionID)
+		}
+	}
+
+	p.OnAction(record("exact"), "gsdk_device_volume")
+	p.OnAction(record("any device"), "gsdk_device_*")
+	p.OnUnmatchedAction(record("fallback"))
+
+	mixer := p.Group("gsdk_device_").Group("mixer_")
+	mixer.OnAction(record("mixer"), "mute")
+	mixer.Fallback(record("mixer fallback"))
+
+	for _, id := range []string{
+		"gsdk_device_volume",
+		"gsdk_device_power",
+		"gsdk_device_mixer_mute",
+		"gsdk_device_mixer_solo",
+		"gsdk_other",
+	} {
+		p.client.Dispatch(client.MessageTypeAction, client.ActionMessage{PluginID: "gsdk", ActionID: id})
+	}
+	p.client.Dispatch(client.MessageTypeAction, client.ActionMessage{PluginID: "other", ActionID: "gsdk_device_volume"})
+
+	assert.Equal(t, []string{
+		"exact:gsdk_device_volume",
+		"any device:gsdk_device_power",
+		"mixer:gsdk_device_mixer_mute",
+		"mixer fallback:gsdk_device_mixer_solo",
+		"fallback:gsdk_other",
+	}, handled)
+
+	assert.Equal(t, []Route{
+		{Pattern: "gsdk_device_mixer_mute", Kind: RouteExact, Handlers: 1},
+		{Pattern: "gsdk_device_volume", Kind: RouteExact, Handlers: 1},
+		{Pattern: "gsdk_device_mixer_*", Kind: RoutePattern, Handlers: 1},
+		{Pattern: "gsdk_device_*", Kind: RoutePattern, Handlers: 1},
+		{Pattern: "*", Kind: RouteFallback, Handlers: 1},
+	}, p.Routes())
+}
+
+func TestPlugin_OnAction_invalidPattern(t *testing.T) {
+	t.Parallel()
+
+	p := &Plugin{
+		ID:     "gsdk",
+		client: client.NewClient(),
+	}
+
+	assert.Panics(t, func() {
+		p.OnAction(func(event client.ActionMessage) {}, "gsdk_[")
+	})
+}
+
+func TestPlugin_OnAction_unsubscribe(t *testing.T) {
+	t.Parallel()
+
+	p := &Plugin{
+		ID:     "gsdk",
+		client: client.NewClient(),
+	}
+
+	exact, pattern, once := 0, 0, 0
+	unsubExact := p.OnAction(func(event client.ActionMessage) { exact++ }, "gsdk_a")
+	unsubPattern := p.OnAction(func(event client.ActionMessage) { pattern++ }, "gsdk_*")
+	p.OnActionOnce(func(event client.ActionMessage) { once++ }, "gsdk_b")
+
+	dispatch := func(id string) {
+		p.client.Dispatch(client.MessageTypeAction, client.ActionMessage{PluginID: "gsdk", ActionID: id})
+	}
+
+	dispatch("gsdk_a")
+	dispatch("gsdk_b")
+	dispatch("gsdk_b")
+
+	unsubExact()
+	dispatch("gsdk_a")
+
+	unsubPattern()
+	dispatch("gsdk_a")
+
+	assert.Equal(t, 1, exact)
+	assert.Equal(t, 2, pattern, "pattern should take over once the exact route is removed")
+	assert.Equal(t, 1, once)
+	assert.Empty(t, p.Routes())
+}
@@ -0,0 +1,221 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Job is a function run periodically by the plugin, as created by Every or Schedule. Jobs
+// stop when the plugin shuts down or finishes, or when Stop is called.
+type Job struct {
+	plugin   *Plugin
+	schedule schedule
+	fn       func(ctx context.Context)
+
+	jitter       time.Duration
+	allowOverlap bool
+
+	ctx     context.Context
+	cancel  context.CancelFunc
+	paused  atomic.Bool
+	running atomic.Bool
+}
+
+// JobOption allows the configuration of a Job when calling Every or Schedule
+type JobOption func(j *Job)
+
+// WithJitter delays each run of the job by a random duration of up to d, spreading out
+// jobs that would otherwise all run at once.
+func WithJitter(d time.Duration) JobOption {
+	return func(j *Job) {
+		j.jitter = d
+	}
+}
+
+// WithOverlap allows a run of the job to start whilst the previous one is still running.
+// By default the run is skipped.
+func WithOverlap() JobOption {
+	return func(j *Job) {
+		j.allowOverlap = true
+	}
+}
+
+// Every runs fn every interval until the plugin stops. The context passed to fn is
+// cancelled when the job stops, so a long run can be abandoned.
+//
+//	p.Every(5*time.Second, func(ctx context.Context) {
+//	    _ = p.UpdateState("gsdk_cpu", readCPU())
+//	}, plugin.WithJitter(time.Second))
+func (p *Plugin) Every(interval time.Duration, fn func(ctx context.Context), opts ...JobOption) *Job {
+	if interval <= 0 {
+		p.panicf("invalid job interval %s, must be positive", interval)
+	}
+
+	return p.startJob(intervalSchedule{interval: interval}, fn, opts)
+}
+
+// Schedule runs fn according to a cron expression until the plugin stops. The expression
+// has five fields; minute, hour, day of month, month and day of week, such as
+// "*/15 9-17 * * mon-fri". The descriptors "@hourly", "@daily", "@weekly", "@monthly",
+// "@yearly" and "@every <duration>" may be used instead. Times are in the local time zone.
+func (p *Plugin) Schedule(spec string, fn func(ctx context.Context), opts ...JobOption) (*Job, error) {
+	s, err := parseSchedule(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.startJob(s, fn, opts), nil
+}
+
+// Pause stops the job running until Resume is called. A run in progress is not interrupted.
+func (j *Job) Pause() {
+	j.paused.Store(true)
+}
+
+// Resume allows a paused job to run again from its next scheduled time
+func (j *Job) Resume() {
+	j.paused.Store(false)
+}
+
+// Paused reports whether the job is paused
+func (j *Job) Paused() bool {
+	return j.paused.Load()
+}
+
+// Stop stops the job for good, cancelling the context of any run in progress
+func (j *Job) Stop() {
+	j.cancel()
+}
+
+// jobs tracks the jobs of a plugin so they can be stopped, and waited for, together
+type jobs struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	stopped bool
+	wg      sync.WaitGroup
+}
+
+// begin records the start of a run, returning false if the jobs have been stopped
+func (js *jobs) begin() bool {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+
+	if js.stopped {
+		return false
+	}
+
+	js.wg.Add(1)
+
+	return true
+}
+
+// jobRunner returns the jobs of the plugin, creating them, stopped when the plugin
+// finishes, if need be
+func (p *Plugin) jobRunner() *jobs {
+	p.jobsOnce.Do(func() {
+		p.jobs = &jobs{}
+		p.jobs.ctx, p.jobs.cancel = context.WithCancel(context.Background())
+
+		go func() {
+			select {
+			case <-p.done:
+				p.jobs.cancel()
+			case <-p.jobs.ctx.Done():
+			}
+		}()
+	})
+
+	return p.jobs
+}
+
+func (p *Plugin) startJob(s schedule, fn func(ctx context.Context), opts []JobOption) *Job {
+	j := &Job{plugin: p, schedule: s, fn: fn}
+	j.ctx, j.cancel = context.WithCancel(p.jobRunner().ctx)
+
+	for _, opt := range opts {
+		opt(j)
+	}
+
+	go j.loop()
+
+	return j
+}
+
+// stopJobs stops every job of the plugin, waiting for runs in progress to finish or the
+// context to be done.
+func (p *Plugin) stopJobs(ctx context.Context) error {
+	js := p.jobRunner()
+	js.cancel()
+
+	js.mu.Lock()
+	js.stopped = true
+	js.mu.Unlock()
+
+	finished := make(chan bool)
+	go func() {
+		js.wg.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("jobs did not finish: %w", ctx.Err())
+	}
+}
+
+func (j *Job) loop() {
+	for {
+		next := j.schedule.next(time.Now())
+		if next.IsZero() {
+			j.plugin.log().Warn("job schedule has no next run, stopping job", "pluginId", j.plugin.ID)
+			return
+		}
+
+		if j.jitter > 0 {
+			next = next.Add(time.Duration(rand.Int63n(int64(j.jitter))))
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-timer.C:
+		case <-j.ctx.Done():
+			timer.Stop()
+			return
+		}
+
+		if j.paused.Load() {
+			continue
+		}
+
+		if !j.allowOverlap && !j.running.CompareAndSwap(false, true) {
+			j.plugin.log().Debug("job still running, skipping run", "pluginId", j.plugin.ID)
+			continue
+		}
+
+		if !j.plugin.jobs.begin() {
+			return
+		}
+
+		go j.runOnce()
+	}
+}
+
+func (j *Job) runOnce() {
+	defer j.plugin.jobs.wg.Done()
+	defer j.running.Store(false)
+	defer func() {
+		if r := recover(); r != nil {
+			j.plugin.log().Error("job panicked", "pluginId", j.plugin.ID, "panic", r)
+		}
+	}()
+
+	j.fn(j.ctx)
+}
@@ -0,0 +1,142 @@
+package plugin
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/marcokaiser/touchportal-golang-sdk/plugin/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlugin_Every(t *testing.T) {
+	t.Parallel()
+
+	p := &Plugin{ID: "test", done: make(chan bool)}
+
+	runs := make(chan bool)
+	release := make(chan bool)
+	j := p.Every(time.Millisecond, func(ctx context.Context) {
+		runs <- true
+
+		select {
+		case <-release:
+		case <-ctx.Done():
+		}
+	})
+
+	// whilst the first run is held no other starts, so none can begin after the pause
+	<-runs
+	j.Pause()
+	assert.True(t, j.Paused())
+	release <- true
+
+	assert.Never(t, func() bool {
+		select {
+		case <-runs:
+			return true
+		default:
+			return false
+		}
+	}, 20*time.Millisecond, time.Millisecond, "paused job ran")
+
+	j.Resume()
+	select {
+	case <-runs:
+	case <-time.After(time.Second):
+		t.Fatal("resumed job did not run")
+	}
+
+	// the job stops once the plugin has finished
+	close(p.done)
+	assert.Eventually(t, func() bool {
+		return p.jobs.ctx.Err() != nil
+	}, time.Second, time.Millisecond)
+}
+
+func TestPlugin_Every_overlap(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		opts        []JobOption
+		wantOverlap bool
+	}{
+		{name: "prevented", wantOverlap: false},
+		{name: "allowed", opts: []JobOption{WithOverlap()}, wantOverlap: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := &Plugin{ID: "test"}
+
+			// each run lasts until the job is stopped
+			started := make(chan bool, 1)
+			j := p.Every(time.Millisecond, func(ctx context.Context) {
+				select {
+				case started <- true:
+				default:
+				}
+				<-ctx.Done()
+			}, tt.opts...)
+			defer j.Stop()
+
+			select {
+			case <-started:
+			case <-time.After(time.Second):
+				t.Fatal("job did not run")
+			}
+
+			overlapped := func() bool {
+				select {
+				case <-started:
+					return true
+				default:
+					return false
+				}
+			}
+
+			if tt.wantOverlap {
+				assert.Eventually(t, overlapped, time.Second, time.Millisecond, "no run started whilst another was running")
+			} else {
+				assert.Never(t, overlapped, 20*time.Millisecond, time.Millisecond, "run started whilst another was running")
+			}
+		})
+	}
+}
+
+func TestPlugin_Shutdown_stopsJobs(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	mc := NewMockPluginClient(ctrl)
+
+	p := &Plugin{ID: "test", client: mc, done: make(chan bool)}
+
+	started := make(chan bool)
+	startOnce := sync.Once{}
+	var finished atomic.Bool
+	p.Every(time.Millisecond, func(ctx context.Context) {
+		startOnce.Do(func() {
+			close(started)
+		})
+		<-ctx.Done()
+		finished.Store(true)
+	})
+	<-started
+
+	mc.EXPECT().StopReceiving()
+	mc.EXPECT().Flush()
+	mc.EXPECT().Close().Do(func() {
+		close(p.done)
+	})
+
+	assert.NoError(t, p.Shutdown(context.Background()))
+	assert.True(t, finished.Load(), "shutdown did not wait for the running job")
+}
@@ -0,0 +1,112 @@
+package plugin
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/marcokaiser/touchportal-golang-sdk/client"
+)
+
+// CreateState adds a state to TouchPortal at runtime, returning ErrUnsupported if the
+// connected TouchPortal does not support doing so.
+func (p *Plugin) CreateState(id, description, defaultValue string) error {
+	return p.createState(id, description, defaultValue, "")
+}
+
+// CreateStateInGroup adds a state to TouchPortal at runtime, as CreateState does, listing it
+// under the given group.
+func (p *Plugin) CreateStateInGroup(id, description, defaultValue, parentGroup string) error {
+	if err := p.require(FeatureStateParentGroup); err != nil {
+		return err
+	}
+
+	return p.createState(id, description, defaultValue, parentGroup)
+}
+
+func (p *Plugin) createState(id, description, defaultValue, parentGroup string) error {
+	err := p.sendFeature(FeatureCreateState, client.NewCreateStateMessage(id, description, defaultValue, parentGroup))
+	if err != nil {
+		return err
+	}
+	p.trackState(id, defaultValue)
+
+	return nil
+}
+
+// RemoveState removes a state added using CreateState
+func (p *Plugin) RemoveState(id string) error {
+	if err := p.sendFeature(FeatureRemoveState, client.NewRemoveStateMessage(id)); err != nil {
+		return err
+	}
+	p.untrackState(id)
+
+	return nil
+}
+
+// UpdateChoices replaces the values of a choice list in the plugins actions
+func (p *Plugin) UpdateChoices(listID string, values []string) error {
+	return p.sendFeature(FeatureChoiceUpdate, client.NewChoiceUpdateMessage(listID, values, ""))
+}
+
+// UpdateChoicesForInstance replaces the values of a choice list in a single action instance,
+// as identified by a client.ListChangeMessage.
+func (p *Plugin) UpdateChoicesForInstance(listID, instanceID string, values []string) error {
+	return p.sendFeature(FeatureChoiceUpdateInstance, client.NewChoiceUpdateMessage(listID, values, instanceID))
+}
+
+// UpdateSetting changes the value of one of the plugins settings
+func (p *Plugin) UpdateSetting(name, value string) error {
+	return p.sendFeature(FeatureSettingUpdate, client.NewSettingUpdateMessage(name, value))
+}
+
+// UpdateConnector moves the sliders bound to one of the plugins connectors to value, which
+// should be within the range 0-100. Where the connector has data, only the sliders whose
+// data matches is moved.
+func (p *Plugin) UpdateConnector(connectorID string, value int, data map[string]string) error {
+	return p.sendFeature(FeatureConnectorUpdate, client.NewConnectorUpdateMessage(p.connectorUpdateID(connectorID, data), value))
+}
+
+// TriggerEvent triggers one of the plugins events, passing it the values of any local states
+func (p *Plugin) TriggerEvent(eventID string, states map[string]string) error {
+	return p.sendFeature(FeatureTriggerEvent, client.NewTriggerEventMessage(eventID, states))
+}
+
+// UpdateActionData changes the range of a number data field of an action instance
+func (p *Plugin) UpdateActionData(instanceID string, data client.ActionDataUpdate) error {
+	return p.sendFeature(FeatureUpdateActionData, client.NewUpdateActionDataMessage(instanceID, data))
+}
+
+// ShowNotification shows the user a notification. When the user clicks one of its options
+// a client.NotificationOptionClickedMessage is sent, which can be waited for using Await.
+func (p *Plugin) ShowNotification(id, title, body string, options ...client.NotificationOption) error {
+	return p.sendFeature(FeatureNotifications, client.NewShowNotificationMessage(id, title, body, options))
+}
+
+// sendFeature sends the message if the connected TouchPortal supports the feature it is a
+// part of, returning ErrUnsupported if not.
+func (p *Plugin) sendFeature(f Feature, msg interface{}) error {
+	if err := p.require(f); err != nil {
+		return err
+	}
+
+	return p.client.SendMessage(msg)
+}
+
+// connectorUpdateID builds the id TouchPortal expects in a connectorUpdate; the connector
+// id prefixed with "pc_" and the plugin id, followed by any data.
+func (p *Plugin) connectorUpdateID(connectorID string, data map[string]string) string {
+	var b strings.Builder
+	b.WriteString("pc_" + p.ID + "_" + connectorID)
+
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		b.WriteString("|" + k + "=" + data[k])
+	}
+
+	return b.String()
+}
@@ -0,0 +1,139 @@
+package plugin
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/marcokaiser/touchportal-golang-sdk/client"
+	. "github.com/marcokaiser/touchportal-golang-sdk/plugin/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlugin_Supports(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		sdkVersion int
+		feature    Feature
+		want       bool
+	}{
+		{name: "not registered", sdkVersion: 0, feature: FeatureCreateState, want: false},
+		{name: "older sdk", sdkVersion: 3, feature: FeatureConnectorUpdate, want: false},
+		{name: "same sdk", sdkVersion: 4, feature: FeatureConnectorUpdate, want: true},
+		{name: "newer sdk", sdkVersion: 7, feature: FeatureTriggerEvent, want: true},
+		{name: "unknown feature", sdkVersion: 7, feature: Feature("teleport"), want: false},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := &Plugin{}
+			p.sdkVersion.Store(int32(tt.sdkVersion))
+			assert.Equal(t, tt.want, p.Supports(tt.feature))
+		})
+	}
+}
+
+func TestPlugin_Supports_registering(t *testing.T) {
+	t.Parallel()
+
+	p := &Plugin{ID: "test"}
+
+	// features may be checked from other goroutines whilst the plugin registers
+	checked := make(chan bool)
+	go func() {
+		defer close(checked)
+
+		for !p.Supports(FeatureTriggerEvent) {
+			runtime.Gosched()
+		}
+	}()
+
+	p.infoReceivedHandler()(client.InfoMessage{SdkVersion: 6})
+	<-checked
+}
+
+func TestPlugin_senders(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		sdkVersion int
+		send       func(p *Plugin) error
+		want       interface{}
+		wantErr    error
+	}{
+		{
+			name:       "create state",
+			sdkVersion: 2,
+			send: func(p *Plugin) error {
+				return p.CreateState("state", "A state", "off")
+			},
+			want: client.NewCreateStateMessage("state", "A state", "off", ""),
+		},
+		{
+			name:       "create state in group unsupported",
+			sdkVersion: 5,
+			send: func(p *Plugin) error {
+				return p.CreateStateInGroup("state", "A state", "off", "Devices")
+			},
+			wantErr: ErrUnsupported,
+		},
+		{
+			name:       "connector update with data",
+			sdkVersion: 4,
+			send: func(p *Plugin) error {
+				return p.UpdateConnector("volume", 50, map[string]string{"output": "speakers", "device": "mixer"})
+			},
+			want: client.NewConnectorUpdateMessage("pc_testPlugin_volume|device=mixer|output=speakers", 50),
+		},
+		{
+			name:       "connector update unsupported",
+			sdkVersion: 3,
+			send: func(p *Plugin) error {
+				return p.UpdateConnector("volume", 50, nil)
+			},
+			wantErr: ErrUnsupported,
+		},
+		{
+			name:       "notification",
+			sdkVersion: 6,
+			send: func(p *Plugin) error {
+				return p.ShowNotification("update", "Update", "An update is available", client.NotificationOption{ID: "get", Title: "Get it"})
+			},
+			want: client.NewShowNotificationMessage("update", "Update", "An update is available", []client.NotificationOption{{ID: "get", Title: "Get it"}}),
+		},
+		{
+			name:       "trigger event before registration",
+			sdkVersion: 0,
+			send: func(p *Plugin) error {
+				return p.TriggerEvent("event", nil)
+			},
+			wantErr: ErrUnsupported,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			mc := NewMockPluginClient(ctrl)
+
+			if tt.want != nil {
+				mc.EXPECT().SendMessage(tt.want).Return(nil)
+			}
+
+			p := &Plugin{ID: "testPlugin", client: mc}
+			p.sdkVersion.Store(int32(tt.sdkVersion))
+
+			err := tt.send(p)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
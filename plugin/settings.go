@@ -2,7 +2,6 @@ package plugin
 
 import (
 	"encoding/json"
-	"log"
 	"reflect"
 
 	"github.com/marcokaiser/touchportal-golang-sdk/client"
@@ -28,11 +27,11 @@ type SettingsUpdated interface {
 //	}
 //
 //	func main() {
-//	    p := NewPlugin(...)
+//	    p := NewPlugin(ctx, ...)
 //	    s := &settings{}
 //
 //	    p.Settings(s)
-//	    p.Register()
+//	    err := p.Register(ctx)
 //	    // p will now contain any settings that TouchPortal returned
 //	}
 //
@@ -41,7 +40,7 @@ type SettingsUpdated interface {
 func (p *Plugin) Settings(s interface{}) {
 	rv := reflect.ValueOf(s)
 	if rv.IsNil() || rv.Kind() != reflect.Ptr || rv.Elem().Type().Kind() != reflect.Struct {
-		log.Panicf("please pass a struct ptr to the plugin.Settings function; %s passed\n", rv.Kind())
+		p.panicf("please pass a struct ptr to the plugin.Settings function; %s passed", rv.Kind())
 	}
 
 	rvs := reflect.ValueOf(s).Elem()
@@ -50,8 +49,8 @@ func (p *Plugin) Settings(s interface{}) {
 
 		kind := field.Type().Kind()
 		if kind != reflect.String && kind != reflect.Int {
-			log.Panicf(
-				"it is only possible to have settings that are strings or integers; field %s is of type %s\n",
+			p.panicf(
+				"it is only possible to have settings that are strings or integers; field %s is of type %s",
 				rvs.Type().Field(i).Name,
 				kind)
 		}
@@ -64,13 +63,13 @@ func (p *Plugin) Settings(s interface{}) {
 		// turn the settings back into json
 		enc, err := json.Marshal(event.Values)
 		if err != nil {
-			log.Panicf("failed to marshal settings back into json: %v\n", err)
+			p.panicf("failed to marshal settings back into json: %v", err)
 		}
 
 		// write the settings to the settings object we were given
 		err = json.Unmarshal(enc, p.settings)
 		if err != nil {
-			log.Panicf("failed to write settings to given settings struct: %v\n", err)
+			p.panicf("failed to write settings to given settings struct: %v", err)
 		}
 
 		obj, ok := p.settings.(SettingsUpdated)
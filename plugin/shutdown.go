@@ -0,0 +1,136 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/marcokaiser/touchportal-golang-sdk/client"
+	"github.com/marcokaiser/touchportal-golang-sdk/internal/sliceutil"
+)
+
+// DefaultShutdownTimeout is how long Shutdown waits for the plugin to shut down when its
+// context has no deadline and no other timeout is set using WithShutdownTimeout.
+const DefaultShutdownTimeout = 5 * time.Second
+
+type shutdownHook struct {
+	fn func(ctx context.Context)
+}
+
+// OnShutdown registers a hook that is run when the plugin shuts down, whether asked to by
+// TouchPortal or by calling Shutdown. Hooks are run in the order they were registered,
+// after the plugin has stopped receiving messages but whilst it can still send them, and
+// should return once the context is done.
+func (p *Plugin) OnShutdown(hook func(ctx context.Context)) client.Unsubscribe {
+	h := &shutdownHook{fn: hook}
+
+	p.hooksMu.Lock()
+	p.shutdownHooks = append(p.shutdownHooks, h)
+	p.hooksMu.Unlock()
+
+	return client.Unsubscribe(func() {
+		p.hooksMu.Lock()
+		defer p.hooksMu.Unlock()
+
+		p.shutdownHooks = sliceutil.Without(p.shutdownHooks, h)
+	})
+}
+
+// Shutdown shuts the plugin down in order. It stops receiving messages from TouchPortal,
+// stops any jobs, waiting for those running to finish, runs the OnShutdown hooks, waits
+// for messages being sent to finish, resets any states given using WithResetStates and
+// finally closes the connection, returning once Done is closed.
+//
+// Should the context be done first the remaining steps are hurried along and its error
+// returned. Shutdown is safe to call more than once, later calls waiting for the first to
+// finish and returning its result.
+func (p *Plugin) Shutdown(ctx context.Context) error {
+	p.shutdownOnce.Do(func() {
+		p.shutdownErr = p.shutdown(ctx)
+	})
+
+	return p.shutdownErr
+}
+
+func (p *Plugin) shutdown(ctx context.Context) error {
+	if _, ok := ctx.Deadline(); !ok {
+		timeout := p.shutdownTimeout
+		if timeout == 0 {
+			timeout = DefaultShutdownTimeout
+		}
+
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	p.log().Info("shutting down", "pluginId", p.ID)
+	p.client.StopReceiving()
+
+	var errs []error
+	if err := p.stopJobs(ctx); err != nil {
+		errs = append(errs, err)
+	}
+
+	p.hooksMu.Lock()
+	hooks := p.shutdownHooks
+	p.hooksMu.Unlock()
+
+	for i, hook := range hooks {
+		if err := runShutdownHook(ctx, hook); err != nil {
+			errs = append(errs, fmt.Errorf("shutdown hook %d: %w", i, err))
+		}
+	}
+
+	ids := make([]string, 0, len(p.stateDefaults))
+	for id := range p.stateDefaults {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		if err := p.UpdateState(id, p.stateDefaults[id]); err != nil {
+			errs = append(errs, fmt.Errorf("unable to reset state %q: %w", id, err))
+		}
+	}
+
+	p.client.Flush()
+	p.client.Close()
+
+	select {
+	case <-p.done:
+	case <-ctx.Done():
+		errs = append(errs, fmt.Errorf("plugin did not stop: %w", ctx.Err()))
+	}
+
+	return errors.Join(errs...)
+}
+
+// runShutdownHook runs the hook until it returns or the context is done, recovering from
+// any panic
+func runShutdownHook(ctx context.Context, hook *shutdownHook) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	finished := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				finished <- fmt.Errorf("panicked: %v", r)
+			}
+		}()
+
+		hook.fn(ctx)
+		finished <- nil
+	}()
+
+	select {
+	case err := <-finished:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("did not finish: %w", ctx.Err())
+	}
+}
@@ -0,0 +1,88 @@
+package plugin
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/marcokaiser/touchportal-golang-sdk/client"
+	. "github.com/marcokaiser/touchportal-golang-sdk/plugin/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlugin_Shutdown(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	mc := NewMockPluginClient(ctrl)
+
+	p := &Plugin{
+		ID:            "test",
+		client:        mc,
+		done:          make(chan bool),
+		stateDefaults: map[string]string{"b": "off", "a": "0"},
+	}
+
+	var order []string
+	p.OnShutdown(func(ctx context.Context) {
+		order = append(order, "first")
+	})
+	unsub := p.OnShutdown(func(ctx context.Context) {
+		order = append(order, "removed")
+	})
+	p.OnShutdown(func(ctx context.Context) {
+		order = append(order, "second")
+	})
+	unsub()
+
+	gomock.InOrder(
+		mc.EXPECT().StopReceiving(),
+		mc.EXPECT().SendMessage(client.NewStateUpdateMessage("a", "0")),
+		mc.EXPECT().SendMessage(client.NewStateUpdateMessage("b", "off")),
+		mc.EXPECT().Flush(),
+		mc.EXPECT().Close().Do(func() {
+			close(p.done)
+		}),
+	)
+
+	err := p.Shutdown(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"first", "second"}, order)
+}
+
+func TestPlugin_Shutdown_deadline(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	mc := NewMockPluginClient(ctrl)
+
+	p := &Plugin{
+		ID:              "test",
+		client:          mc,
+		done:            make(chan bool),
+		shutdownTimeout: 20 * time.Millisecond,
+	}
+
+	// a hook that ignores the deadline must not hold up the shutdown
+	block := make(chan bool)
+	t.Cleanup(func() {
+		close(block)
+	})
+	p.OnShutdown(func(ctx context.Context) {
+		<-block
+	})
+
+	later := false
+	p.OnShutdown(func(ctx context.Context) {
+		later = true
+	})
+
+	mc.EXPECT().StopReceiving()
+	mc.EXPECT().Flush()
+	mc.EXPECT().Close()
+
+	err := p.Shutdown(context.Background())
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.False(t, later, "hook run after the deadline passed")
+}
@@ -0,0 +1,120 @@
+package plugin
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+
+	"github.com/marcokaiser/touchportal-golang-sdk/client"
+	"github.com/marcokaiser/touchportal-golang-sdk/internal/sliceutil"
+)
+
+// ErrNoRoute is returned by DispatchAction when no handler is registered for the action
+var ErrNoRoute = errors.New("no handler registered for action")
+
+type stateUpdateObserver struct {
+	fn func(id, value string)
+}
+
+// States returns the value last sent to TouchPortal of each state, by id
+func (p *Plugin) States() map[string]string {
+	p.statesMu.RLock()
+	defer p.statesMu.RUnlock()
+
+	states := make(map[string]string, len(p.states))
+	for id, value := range p.states {
+		states[id] = value
+	}
+
+	return states
+}
+
+// State returns the value last sent to TouchPortal of the state, reporting whether one
+// has been sent
+func (p *Plugin) State(id string) (string, bool) {
+	p.statesMu.RLock()
+	defer p.statesMu.RUnlock()
+
+	value, ok := p.states[id]
+
+	return value, ok
+}
+
+// OnStateUpdate registers a handler that is called whenever the plugin sends TouchPortal
+// the value of a state, allowing the states to be mirrored elsewhere.
+func (p *Plugin) OnStateUpdate(handler func(id, value string)) client.Unsubscribe {
+	o := &stateUpdateObserver{fn: handler}
+
+	p.statesMu.Lock()
+	p.stateObservers = append(p.stateObservers, o)
+	p.statesMu.Unlock()
+
+	return client.Unsubscribe(func() {
+		p.statesMu.Lock()
+		defer p.statesMu.Unlock()
+
+		p.stateObservers = sliceutil.Without(p.stateObservers, o)
+	})
+}
+
+// trackState records the value sent for a state, telling any observers
+func (p *Plugin) trackState(id, value string) {
+	p.statesMu.Lock()
+	if p.states == nil {
+		p.states = make(map[string]string)
+	}
+	p.states[id] = value
+	observers := p.stateObservers
+	p.statesMu.Unlock()
+
+	for _, o := range observers {
+		o.fn(id, value)
+	}
+}
+
+func (p *Plugin) untrackState(id string) {
+	p.statesMu.Lock()
+	defer p.statesMu.Unlock()
+
+	delete(p.states, id)
+}
+
+// DispatchAction handles an action as though TouchPortal had sent it, passing it through
+// any middleware to the registered handlers. It allows actions to be triggered from
+// elsewhere, such as a bridge to another system, returning ErrNoRoute if no handler
+// would receive it.
+func (p *Plugin) DispatchAction(actionID string, data map[string]string) error {
+	if len(p.actionRouter().match(actionID)) == 0 {
+		return ErrNoRoute
+	}
+
+	ids := make([]string, 0, len(data))
+	for id := range data {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	values := make([]client.DataValue, 0, len(ids))
+	for _, id := range ids {
+		value, err := json.Marshal(data[id])
+		if err != nil {
+			return err
+		}
+
+		values = append(values, client.DataValue{ID: id, Value: value})
+	}
+
+	raw, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+
+	p.client.Dispatch(client.MessageTypeAction, client.ActionMessage{
+		Message:  client.Message{Type: client.MessageTypeAction},
+		PluginID: p.ID,
+		ActionID: actionID,
+		Data:     raw,
+	})
+
+	return nil
+}
@@ -0,0 +1,318 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Migration updates the values of a Store from one version to the next
+type Migration func(values map[string]json.RawMessage) error
+
+// StoreOption allows the configuration of a Store when calling OpenStore
+type StoreOption func(s *Store)
+
+// WithStoreDir sets the directory the store file is kept in
+func WithStoreDir(dir string) StoreOption {
+	return func(s *Store) {
+		s.dir = dir
+	}
+}
+
+// WithMigrations sets the migrations of the store. The version of a store is the number of
+// migrations applied to it, those it has not yet had are applied in order when it is opened.
+// Migrations must only ever be added to the end of the list.
+func WithMigrations(migrations ...Migration) StoreOption {
+	return func(s *Store) {
+		s.migrations = migrations
+	}
+}
+
+// WithStateSnapshots has the plugin save the values of its bound states to the store when
+// it shuts down, restoring them when they are bound after the plugin next starts.
+func WithStateSnapshots() StoreOption {
+	return func(s *Store) {
+		s.snapshots = true
+	}
+}
+
+// Store is a key/value store persisted to a file, for values that should survive the plugin
+// restarting. Each plugin has its own file, named after its id, which is replaced atomically
+// on every change so it is never left half written.
+type Store struct {
+	dir        string
+	path       string
+	migrations []Migration
+	snapshots  bool
+
+	mu      sync.Mutex
+	version int
+	values  map[string]json.RawMessage
+}
+
+type storeFile struct {
+	Version int                        `json:"version"`
+	Values  map[string]json.RawMessage `json:"values"`
+}
+
+// OpenStore opens the store of the plugin, creating it if need be. Without WithStoreDir it
+// is kept in the directory of the plugins executable, which is the folder the plugin is
+// installed in.
+//
+//	s, err := p.OpenStore(plugin.WithStateSnapshots())
+//	runs, _, err := plugin.Lookup[int](s, "runs")
+//	err = s.Set("runs", runs+1)
+func (p *Plugin) OpenStore(opts ...StoreOption) (*Store, error) {
+	s, err := OpenStore(p.ID, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.snapshots {
+		p.snapshotStates(s)
+	}
+
+	return s, nil
+}
+
+// OpenStore opens the store with the given id, as Plugin.OpenStore does, for use outside of
+// a plugin.
+func OpenStore(id string, opts ...StoreOption) (*Store, error) {
+	if id == "" || strings.ContainsAny(id, `/\:`) {
+		return nil, fmt.Errorf("invalid store id %q", id)
+	}
+
+	s := &Store{values: make(map[string]json.RawMessage)}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.dir == "" {
+		dir, err := defaultStoreDir()
+		if err != nil {
+			return nil, err
+		}
+		s.dir = dir
+	}
+
+	s.path = filepath.Join(s.dir, id+".store.json")
+
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// defaultStoreDir is the folder the plugin is installed in
+func defaultStoreDir() (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("unable to find a directory for the store: %w", err)
+	}
+
+	return filepath.Dir(exe), nil
+}
+
+// load reads the store file, applying any migrations it has not yet had
+func (s *Store) load() error {
+	b, err := os.ReadFile(s.path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("unable to read store: %w", err)
+	}
+
+	if err == nil {
+		var f storeFile
+		if err := json.Unmarshal(b, &f); err != nil {
+			return fmt.Errorf("unable to parse store %s: %w", s.path, err)
+		}
+
+		s.version = f.Version
+		if f.Values != nil {
+			s.values = f.Values
+		}
+	}
+
+	if s.version > len(s.migrations) {
+		return fmt.Errorf("store %s is at version %d, newer than the %d migrations known", s.path, s.version, len(s.migrations))
+	}
+
+	if s.version == len(s.migrations) {
+		return nil
+	}
+
+	for i := s.version; i < len(s.migrations); i++ {
+		if err := s.migrations[i](s.values); err != nil {
+			return fmt.Errorf("unable to migrate store to version %d: %w", i+1, err)
+		}
+	}
+	s.version = len(s.migrations)
+
+	return s.save()
+}
+
+// save writes the store to a temporary file before renaming it over the store file. It must
+// be called with mu held, other than whilst loading.
+func (s *Store) save() error {
+	b, err := json.MarshalIndent(storeFile{Version: s.version, Values: s.values}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to marshal store: %w", err)
+	}
+
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return fmt.Errorf("unable to create store directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(s.dir, filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("unable to write store: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(b)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), s.path)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to write store: %w", err)
+	}
+
+	return nil
+}
+
+// Get unmarshals the value of key into v, reporting whether the key was found
+func (s *Store) Get(key string, v interface{}) (bool, error) {
+	s.mu.Lock()
+	raw, ok := s.values[key]
+	s.mu.Unlock()
+
+	if !ok {
+		return false, nil
+	}
+
+	if err := json.Unmarshal(raw, v); err != nil {
+		return true, fmt.Errorf("unable to read %q from store: %w", key, err)
+	}
+
+	return true, nil
+}
+
+// Set stores v, which must marshal to JSON, under key and saves the store
+func (s *Store) Set(key string, v interface{}) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("unable to store %q: %w", key, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.values[key] = raw
+
+	return s.save()
+}
+
+// Delete removes key from the store and saves it
+func (s *Store) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.values[key]; !ok {
+		return nil
+	}
+	delete(s.values, key)
+
+	return s.save()
+}
+
+// Keys returns the keys of the store in order
+func (s *Store) Keys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := make([]string, 0, len(s.values))
+	for k := range s.values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// Version returns the version of the store, the number of migrations applied to it
+func (s *Store) Version() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.version
+}
+
+// Lookup returns the value of key as a T, reporting whether the key was found
+func Lookup[T any](s *Store, key string) (T, bool, error) {
+	var v T
+	ok, err := s.Get(key, &v)
+
+	return v, ok, err
+}
+
+// stateKey is the key the snapshot of a bound state is stored under
+func stateKey(id string) string {
+	return "state:" + id
+}
+
+// snapshotStates restores the bound states of the plugin from the store, now and as they
+// are bound, saving them again when the plugin shuts down.
+func (p *Plugin) snapshotStates(s *Store) {
+	p.bindingsMu.Lock()
+	p.snapshots = s
+	bindings := p.bindings
+	p.bindingsMu.Unlock()
+
+	for _, b := range bindings {
+		p.restoreState(s, b)
+	}
+
+	p.OnShutdown(func(ctx context.Context) {
+		p.bindingsMu.Lock()
+		bindings := p.bindings
+		p.bindingsMu.Unlock()
+
+		for _, b := range bindings {
+			raw, ok := b.snapshot()
+			if !ok {
+				continue
+			}
+
+			if err := s.Set(stateKey(b.ID()), raw); err != nil {
+				p.log().Warn("unable to snapshot bound state", "pluginId", p.ID, "stateId", b.ID(), "error", err)
+			}
+		}
+	})
+}
+
+func (p *Plugin) restoreState(s *Store, b boundState) {
+	var raw json.RawMessage
+
+	ok, err := s.Get(stateKey(b.ID()), &raw)
+	if err == nil && ok {
+		err = b.restore(raw)
+	}
+
+	if err != nil {
+		p.log().Warn("unable to restore bound state", "pluginId", p.ID, "stateId", b.ID(), "error", err)
+	}
+}
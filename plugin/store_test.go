@@ -0,0 +1,149 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/marcokaiser/touchportal-golang-sdk/client"
+	. "github.com/marcokaiser/touchportal-golang-sdk/plugin/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStore(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	s, err := OpenStore("test", WithStoreDir(dir))
+	assert.NoError(t, err)
+
+	_, ok, err := Lookup[int](s, "runs")
+	assert.False(t, ok)
+	assert.NoError(t, err)
+
+	assert.NoError(t, s.Set("runs", 3))
+	assert.NoError(t, s.Set("name", "mixer"))
+	assert.NoError(t, s.Delete("name"))
+
+	// another plugin has its own store in the same directory
+	other, err := OpenStore("other", WithStoreDir(dir))
+	assert.NoError(t, err)
+	assert.Empty(t, other.Keys())
+
+	reopened, err := OpenStore("test", WithStoreDir(dir))
+	assert.NoError(t, err)
+
+	runs, ok, err := Lookup[int](reopened, "runs")
+	assert.True(t, ok)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, runs)
+	assert.Equal(t, []string{"runs"}, reopened.Keys())
+
+	_, _, err = Lookup[string](reopened, "runs")
+	assert.Error(t, err, "value read as the wrong type")
+
+	// the file of a store is only written once it is changed
+	files, _ := filepath.Glob(filepath.Join(dir, "*"))
+	assert.Equal(t, []string{filepath.Join(dir, "test.store.json")}, files, "temporary files left behind")
+}
+
+func TestStore_defaultDir(t *testing.T) {
+	t.Parallel()
+
+	exe, err := os.Executable()
+	assert.NoError(t, err)
+
+	// the store is kept beside the plugins executable, whatever the plugins install folder
+	// is named, and is told apart from other stores there by its id
+	dir, err := defaultStoreDir()
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Dir(exe), dir)
+}
+
+func TestStore_migrations(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "test.store.json"), []byte(`{"version":1,"values":{"count":"7"}}`), 0o644)
+	assert.NoError(t, err)
+
+	applied := 0
+	migrations := []Migration{
+		func(values map[string]json.RawMessage) error {
+			t.Error("migration already applied to the store was applied again")
+			return nil
+		},
+		func(values map[string]json.RawMessage) error {
+			applied++
+
+			// counts were once stored as strings
+			var count string
+			if err := json.Unmarshal(values["count"], &count); err != nil {
+				return err
+			}
+			values["count"] = json.RawMessage(count)
+
+			return nil
+		},
+	}
+
+	s, err := OpenStore("test", WithStoreDir(dir), WithMigrations(migrations...))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, s.Version())
+
+	count, _, err := Lookup[int](s, "count")
+	assert.NoError(t, err)
+	assert.Equal(t, 7, count)
+
+	// the migrated store is saved, so migrating is not repeated
+	_, err = OpenStore("test", WithStoreDir(dir), WithMigrations(migrations...))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, applied)
+
+	// a store newer than the plugin is not touched
+	_, err = OpenStore("test", WithStoreDir(dir), WithMigrations(migrations[0]))
+	assert.Error(t, err)
+}
+
+func TestPlugin_OpenStore_stateSnapshots(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "test.store.json"), []byte(`{"version":0,"values":{"state:counter":41}}`), 0o644)
+	assert.NoError(t, err)
+
+	ctrl := gomock.NewController(t)
+	mc := NewMockPluginClient(ctrl)
+	mc.EXPECT().AddMessageHandler(client.MessageTypeInfo, gomock.Any())
+
+	p := &Plugin{ID: "test", client: mc, done: make(chan bool)}
+
+	_, err = p.OpenStore(WithStoreDir(dir), WithStateSnapshots())
+	assert.NoError(t, err)
+
+	// a restored state is sent to touchportal when it is bound
+	mc.EXPECT().SendMessage(client.NewStateUpdateMessage("counter", "41"))
+	mc.EXPECT().SendMessage(client.NewStateUpdateMessage("counter", "42"))
+
+	counter := BindState[int](p, "counter", nil)
+	assert.Equal(t, 41, counter.Get())
+	assert.NoError(t, counter.Set(42))
+
+	mc.EXPECT().StopReceiving()
+	mc.EXPECT().Flush()
+	mc.EXPECT().Close().Do(func() {
+		close(p.done)
+	})
+	assert.NoError(t, p.Shutdown(context.Background()))
+
+	s, err := OpenStore("test", WithStoreDir(dir))
+	assert.NoError(t, err)
+
+	saved, _, err := Lookup[int](s, "state:counter")
+	assert.NoError(t, err)
+	assert.Equal(t, 42, saved)
+}
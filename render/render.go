@@ -0,0 +1,133 @@
+// Package render draws the small images used as the values of image states, such as
+// button icons showing a value, using only the standard library.
+//
+//	img := render.Gauge(image.Pt(128, 128), cpu/100, fmt.Sprintf("%.0f%%", cpu), render.Style{})
+//	_ = p.UpdateImageState("gsdk_cpu_icon", img)
+//
+// Text is drawn with a small built in bitmap font of the printable ASCII characters,
+// scaled up to fill the space it is given.
+package render
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// Style sets the colours and spacing of an image. Unset fields take their value from
+// DefaultStyle, so the zero Style draws images in the default style.
+type Style struct {
+	// Background fills the image, color.Transparent leaving it clear
+	Background color.Color
+	// Foreground is the colour of text
+	Foreground color.Color
+	// Accent is the colour of the filled part of bars and gauges, and of sparklines
+	Accent color.Color
+	// Track is the colour of the unfilled part of bars and gauges, and under sparklines
+	Track color.Color
+	// Padding is the space, in pixels, left clear around the edge of the image
+	Padding int
+}
+
+// DefaultStyle is the style of images drawn with the zero Style
+var DefaultStyle = Style{
+	Background: color.RGBA{R: 0x1e, G: 0x1e, B: 0x1e, A: 0xff},
+	Foreground: color.White,
+	Accent:     color.RGBA{R: 0x3d, G: 0x9b, B: 0xe9, A: 0xff},
+	Track:      color.RGBA{R: 0x4a, G: 0x4a, B: 0x4a, A: 0xff},
+	Padding:    4,
+}
+
+// Colours of the usual states of whatever a badge shows
+var (
+	StatusOK      color.Color = color.RGBA{R: 0x2e, G: 0xa0, B: 0x43, A: 0xff}
+	StatusWarning color.Color = color.RGBA{R: 0xe3, G: 0xa0, B: 0x08, A: 0xff}
+	StatusError   color.Color = color.RGBA{R: 0xd7, G: 0x3a, B: 0x49, A: 0xff}
+	StatusUnknown color.Color = color.RGBA{R: 0x6e, G: 0x76, B: 0x81, A: 0xff}
+)
+
+func (s Style) withDefaults() Style {
+	if s.Background == nil {
+		s.Background = DefaultStyle.Background
+	}
+
+	if s.Foreground == nil {
+		s.Foreground = DefaultStyle.Foreground
+	}
+
+	if s.Accent == nil {
+		s.Accent = DefaultStyle.Accent
+	}
+
+	if s.Track == nil {
+		s.Track = DefaultStyle.Track
+	}
+
+	if s.Padding == 0 {
+		s.Padding = DefaultStyle.Padding
+	}
+
+	return s
+}
+
+// canvas returns an image of the given size filled with the background of the style,
+// along with the area within its padding
+func (s Style) canvas(size image.Point) (*image.RGBA, image.Rectangle) {
+	img := image.NewRGBA(image.Rectangle{Max: size})
+	draw.Draw(img, img.Bounds(), image.NewUniform(s.Background), image.Point{}, draw.Src)
+
+	area := img.Bounds().Inset(s.Padding)
+	if area.Empty() {
+		area = img.Bounds()
+	}
+
+	return img, area
+}
+
+// fill draws c over dst wherever inside reports the point as within a shape. Each pixel
+// is sampled four times, so the edges of shapes are smoothed.
+func fill(dst *image.RGBA, area image.Rectangle, c color.Color, inside func(x, y float64) bool) {
+	mask := image.NewAlpha(area)
+
+	offsets := [4][2]float64{{0.25, 0.25}, {0.75, 0.25}, {0.25, 0.75}, {0.75, 0.75}}
+	for y := area.Min.Y; y < area.Max.Y; y++ {
+		for x := area.Min.X; x < area.Max.X; x++ {
+			covered := 0
+			for _, o := range offsets {
+				if inside(float64(x)+o[0], float64(y)+o[1]) {
+					covered++
+				}
+			}
+
+			if covered > 0 {
+				mask.SetAlpha(x, y, color.Alpha{A: uint8(covered * 0xff / len(offsets))})
+			}
+		}
+	}
+
+	draw.DrawMask(dst, area, image.NewUniform(c), image.Point{}, mask, area.Min, draw.Over)
+}
+
+// contrast returns black or white, whichever is easier to read on c
+func contrast(c color.Color) color.Color {
+	r, g, b, _ := c.RGBA()
+
+	// relative luminance, weighted as the eye sees each channel
+	if 0.2126*float64(r)+0.7152*float64(g)+0.0722*float64(b) > 0.5*0xffff {
+		return color.Black
+	}
+
+	return color.White
+}
+
+func clamp01(f float64) float64 {
+	if f < 0 {
+		return 0
+	}
+
+	if f > 1 {
+		return 1
+	}
+
+	return f
+}
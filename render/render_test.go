@@ -0,0 +1,100 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// rgba returns the colour of c as color.RGBA, for comparison
+func rgba(c color.Color) color.RGBA {
+	return color.RGBAModel.Convert(c).(color.RGBA)
+}
+
+func TestFitText(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		text      string
+		size      image.Point
+		wantLines []string
+		wantScale int
+	}{
+		{name: "single word", text: "OK", size: image.Pt(64, 64), wantLines: []string{"OK"}, wantScale: 5},
+		{name: "wrapped at spaces", text: "on air", size: image.Pt(64, 64), wantLines: []string{"on", "air"}, wantScale: 3},
+		{name: "explicit lines", text: "a\nb", size: image.Pt(100, 30), wantLines: []string{"a", "b"}, wantScale: 2},
+		{name: "broken words", text: "abcdefgh", size: image.Pt(23, 30), wantLines: []string{"abcd", "efgh"}, wantScale: 1},
+		{name: "cut short", text: "a b c d", size: image.Pt(5, 15), wantLines: []string{"a", "b"}, wantScale: 1},
+		{name: "empty", text: " ", size: image.Pt(64, 64), wantLines: nil, wantScale: 1},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			lines, scale := fitText(tt.text, tt.size)
+			assert.Equal(t, tt.wantLines, lines)
+			assert.Equal(t, tt.wantScale, scale)
+		})
+	}
+}
+
+func TestImages(t *testing.T) {
+	t.Parallel()
+
+	size := image.Pt(96, 64)
+	style := Style{Accent: color.RGBA{R: 0xff, A: 0xff}, Track: color.RGBA{B: 0xff, A: 0xff}}
+
+	tests := []struct {
+		name  string
+		img   *image.RGBA
+		at    image.Point
+		color color.Color
+	}{
+		{name: "text background", img: Text(size, "hi", Style{}), at: image.Pt(1, 1), color: DefaultStyle.Background},
+		{name: "progress filled", img: ProgressBar(size, 0.5, "", style), at: image.Pt(30, 32), color: style.Accent},
+		{name: "progress empty", img: ProgressBar(size, 0.5, "", style), at: image.Pt(70, 32), color: style.Track},
+		{name: "progress label leaves bar at bottom", img: ProgressBar(size, 1, "50%", style), at: image.Pt(48, 52), color: style.Accent},
+		{name: "gauge start filled", img: Gauge(size, 0.25, "", style), at: image.Pt(26, 44), color: style.Accent},
+		{name: "gauge end empty", img: Gauge(size, 0.25, "", style), at: image.Pt(69, 44), color: style.Track},
+		{name: "badge", img: Badge(size, StatusOK, "", Style{}), at: image.Pt(48, 8), color: StatusOK},
+		{name: "sparkline under line", img: Sparkline(size, []float64{0, 1}, style), at: image.Pt(80, 58), color: style.Track},
+		{name: "sparkline line", img: Sparkline(size, []float64{5, 5}, style), at: image.Pt(48, 32), color: style.Accent},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, size, tt.img.Bounds().Size())
+			assert.Equal(t, rgba(tt.color), tt.img.RGBAAt(tt.at.X, tt.at.Y))
+		})
+	}
+}
+
+func TestBadge_contrast(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, color.Black, contrast(StatusWarning))
+	assert.Equal(t, color.White, contrast(StatusError))
+}
+
+func TestHistory(t *testing.T) {
+	t.Parallel()
+
+	h := NewHistory(3)
+	assert.Empty(t, h.Values())
+
+	h.Add(1)
+	h.Add(2)
+	assert.Equal(t, []float64{1, 2}, h.Values())
+
+	h.Add(3)
+	h.Add(4)
+	assert.Equal(t, []float64{2, 3, 4}, h.Values())
+}
@@ -0,0 +1,131 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// gaugeStart and gaugeSweep are the angle, in degrees clockwise from the right, at which
+// a gauge starts and how far round it goes, leaving a gap at the bottom
+const (
+	gaugeStart = 135
+	gaugeSweep = 270
+)
+
+// ProgressBar draws a bar filled to fraction, from 0 to 1, with label above it. Without a
+// label the bar is centred within the image.
+func ProgressBar(size image.Point, fraction float64, label string, style Style) *image.RGBA {
+	style = style.withDefaults()
+	img, area := style.canvas(size)
+
+	height := area.Dy() / 3
+	if height < 1 {
+		height = 1
+	}
+
+	top := area.Min.Y + (area.Dy()-height)/2
+	if label != "" {
+		top = area.Max.Y - height
+		drawFitted(img, image.Rect(area.Min.X, area.Min.Y, area.Max.X, top-style.Padding), label, style)
+	}
+	bar := image.Rect(area.Min.X, top, area.Max.X, top+height)
+
+	shape := roundedRect(bar, float64(height)/2)
+	filled := float64(bar.Min.X) + clamp01(fraction)*float64(bar.Dx())
+
+	fill(img, bar, style.Track, shape)
+	fill(img, bar, style.Accent, func(x, y float64) bool {
+		return x < filled && shape(x, y)
+	})
+
+	return img
+}
+
+// Gauge draws a dial filled to fraction, from 0 to 1, with label in the middle
+func Gauge(size image.Point, fraction float64, label string, style Style) *image.RGBA {
+	style = style.withDefaults()
+	img, area := style.canvas(size)
+
+	d := area.Dx()
+	if area.Dy() < d {
+		d = area.Dy()
+	}
+
+	cx := float64(area.Min.X) + float64(area.Dx())/2
+	cy := float64(area.Min.Y) + float64(area.Dy())/2
+	outer := float64(d) / 2
+	inner := outer * 0.75
+	filled := clamp01(fraction) * gaugeSweep
+
+	// angle returns how far round the gauge the point is, in degrees
+	angle := func(x, y float64) (float64, bool) {
+		r := math.Hypot(x-cx, y-cy)
+		if r < inner || r > outer {
+			return 0, false
+		}
+
+		a := math.Atan2(y-cy, x-cx)*180/math.Pi - gaugeStart
+		a = math.Mod(a+720, 360)
+
+		return a, a <= gaugeSweep
+	}
+
+	fill(img, area, style.Track, func(x, y float64) bool {
+		_, ok := angle(x, y)
+		return ok
+	})
+	fill(img, area, style.Accent, func(x, y float64) bool {
+		a, ok := angle(x, y)
+		return ok && a <= filled
+	})
+
+	// the label fits within the square inside the ring
+	side := int(inner * 2 / math.Sqrt2)
+	labelArea := image.Rect(0, 0, side, side).Add(image.Pt(int(cx)-side/2, int(cy)-side/2))
+	drawFitted(img, labelArea, label, style)
+
+	return img
+}
+
+// Badge draws label on a rounded background of the status colour, such as StatusOK. The
+// label is drawn in black or white, whichever is easier to read, unless the style sets a
+// Foreground.
+func Badge(size image.Point, status color.Color, label string, style Style) *image.RGBA {
+	foreground := style.Foreground
+	if foreground == nil {
+		foreground = contrast(status)
+	}
+
+	style = style.withDefaults()
+	style.Foreground = foreground
+
+	img, area := style.canvas(size)
+
+	radius := area.Dx()
+	if area.Dy() < radius {
+		radius = area.Dy()
+	}
+
+	fill(img, area, status, roundedRect(area, float64(radius)/4))
+	drawFitted(img, area.Inset(radius/8), label, style)
+
+	return img
+}
+
+// roundedRect returns whether a point is within r, its corners rounded to radius
+func roundedRect(r image.Rectangle, radius float64) func(x, y float64) bool {
+	minX, minY := float64(r.Min.X)+radius, float64(r.Min.Y)+radius
+	maxX, maxY := float64(r.Max.X)-radius, float64(r.Max.Y)-radius
+
+	return func(x, y float64) bool {
+		if x < float64(r.Min.X) || x > float64(r.Max.X) || y < float64(r.Min.Y) || y > float64(r.Max.Y) {
+			return false
+		}
+
+		dx := math.Max(0, math.Max(minX-x, x-maxX))
+		dy := math.Max(0, math.Max(minY-y, y-maxY))
+
+		return dx*dx+dy*dy <= radius*radius
+	}
+}
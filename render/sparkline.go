@@ -0,0 +1,134 @@
+package render
+
+import (
+	"image"
+	"math"
+	"sync"
+)
+
+// Sparkline draws values as a line graph, oldest first, scaled to fit the image. The area
+// under the line is filled with the track colour of the style.
+func Sparkline(size image.Point, values []float64, style Style) *image.RGBA {
+	style = style.withDefaults()
+	img, area := style.canvas(size)
+
+	if len(values) == 0 {
+		return img
+	}
+
+	lo, hi := values[0], values[0]
+	for _, v := range values {
+		lo = math.Min(lo, v)
+		hi = math.Max(hi, v)
+	}
+
+	points := make([][2]float64, len(values))
+	for i, v := range values {
+		x := float64(area.Min.X) + float64(area.Dx())/2
+		if len(values) > 1 {
+			x = float64(area.Min.X) + float64(i)*float64(area.Dx()-1)/float64(len(values)-1)
+		}
+
+		// a flat line is drawn through the middle
+		y := float64(area.Min.Y) + float64(area.Dy())/2
+		if hi > lo {
+			y = float64(area.Max.Y-1) - (v-lo)/(hi-lo)*float64(area.Dy()-1)
+		}
+
+		points[i] = [2]float64{x + 0.5, y + 0.5}
+	}
+
+	fill(img, area, style.Track, func(x, y float64) bool {
+		return y >= lineAt(points, x)
+	})
+
+	width := math.Max(1.5, float64(area.Dy())/24)
+	fill(img, area, style.Accent, func(x, y float64) bool {
+		return nearLine(points, x, y, width/2)
+	})
+
+	return img
+}
+
+// lineAt returns the height of the line through points at x
+func lineAt(points [][2]float64, x float64) float64 {
+	if x <= points[0][0] {
+		return points[0][1]
+	}
+
+	for i := 1; i < len(points); i++ {
+		a, b := points[i-1], points[i]
+		if x <= b[0] {
+			return a[1] + (x-a[0])/(b[0]-a[0])*(b[1]-a[1])
+		}
+	}
+
+	return points[len(points)-1][1]
+}
+
+// nearLine reports whether the point is within distance of the line through points
+func nearLine(points [][2]float64, x, y, distance float64) bool {
+	if len(points) == 1 {
+		return math.Hypot(x-points[0][0], y-points[0][1]) <= distance
+	}
+
+	for i := 1; i < len(points); i++ {
+		a, b := points[i-1], points[i]
+		if x < a[0]-distance || x > b[0]+distance {
+			continue
+		}
+
+		// the distance to the closest point of the segment
+		dx, dy := b[0]-a[0], b[1]-a[1]
+		t := ((x-a[0])*dx + (y-a[1])*dy) / (dx*dx + dy*dy)
+		t = clamp01(t)
+
+		if math.Hypot(x-(a[0]+t*dx), y-(a[1]+t*dy)) <= distance {
+			return true
+		}
+	}
+
+	return false
+}
+
+// History keeps the latest values of something, for drawing as a Sparkline. It is safe
+// for concurrent use.
+type History struct {
+	mu     sync.Mutex
+	values []float64
+	next   int
+	full   bool
+}
+
+// NewHistory creates a History keeping the latest size values
+func NewHistory(size int) *History {
+	if size < 1 {
+		size = 1
+	}
+
+	return &History{values: make([]float64, size)}
+}
+
+// Add records a value, forgetting the oldest once the history is full
+func (h *History) Add(v float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.values[h.next] = v
+	h.next = (h.next + 1) % len(h.values)
+	if h.next == 0 {
+		h.full = true
+	}
+}
+
+// Values returns the values recorded, oldest first
+func (h *History) Values() []float64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !h.full {
+		return append([]float64(nil), h.values[:h.next]...)
+	}
+
+	return append(append([]float64(nil), h.values[h.next:]...), h.values[:h.next]...)
+}
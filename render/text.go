@@ -0,0 +1,111 @@
+package render
+
+import (
+	"image"
+	"strings"
+)
+
+// Text draws text as large as it fits, centred within the image. Lines are wrapped at
+// spaces where that allows a larger size, and may be broken using "\n".
+func Text(size image.Point, text string, style Style) *image.RGBA {
+	style = style.withDefaults()
+
+	img, area := style.canvas(size)
+	drawFitted(img, area, text, style)
+
+	return img
+}
+
+// drawFitted draws text as large as it fits, centred within area
+func drawFitted(img *image.RGBA, area image.Rectangle, text string, style Style) {
+	lines, scale := fitText(text, area.Size())
+	if len(lines) == 0 {
+		return
+	}
+
+	height := len(lines)*lineAdvance*scale - scale
+	y := area.Min.Y + (area.Dy()-height)/2
+
+	for _, line := range lines {
+		x := area.Min.X + (area.Dx()-textWidth(line)*scale)/2
+		drawText(img, image.Pt(x, y), scale, line, style.Foreground)
+
+		y += lineAdvance * scale
+	}
+}
+
+// fitText finds the largest scale at which the text, wrapped to the width, fits within
+// size, returning the wrapped lines. Breaking words is avoided unless there is no other
+// way to fit the text, and text that does not fit even at a scale of one is cut short to
+// the lines that do.
+func fitText(text string, size image.Point) ([]string, int) {
+	if strings.TrimSpace(text) == "" {
+		return nil, 1
+	}
+
+	maxScale := size.Y / glyphHeight
+
+	for _, allowBreaks := range []bool{false, true} {
+		for scale := maxScale; scale >= 1; scale-- {
+			lines, broken := wrap(text, (size.X+scale)/(glyphAdvance*scale))
+			if lines == nil || (broken && !allowBreaks) {
+				continue
+			}
+
+			if len(lines)*lineAdvance*scale-scale <= size.Y {
+				return lines, scale
+			}
+		}
+	}
+
+	lines, _ := wrap(text, (size.X+1)/glyphAdvance)
+	if fit := (size.Y + 1) / lineAdvance; len(lines) > fit {
+		lines = lines[:fit]
+	}
+
+	return lines, 1
+}
+
+// wrap splits text into lines of at most width characters, breaking at spaces where it
+// can and within words where it must, reporting whether any word was broken. It returns
+// nil if not even a single character fits.
+func wrap(text string, width int) ([]string, bool) {
+	if width < 1 {
+		return nil, false
+	}
+
+	var (
+		lines  []string
+		broken bool
+	)
+	for _, paragraph := range strings.Split(text, "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			for len([]rune(word)) > width {
+				if line != "" {
+					lines = append(lines, line)
+					line = ""
+				}
+
+				r := []rune(word)
+				lines = append(lines, string(r[:width]))
+				word = string(r[width:])
+				broken = true
+			}
+
+			switch {
+			case line == "":
+				line = word
+			case len([]rune(line))+1+len([]rune(word)) <= width:
+				line += " " + word
+			default:
+				lines = append(lines, line)
+				line = word
+			}
+		}
+
+		lines = append(lines, line)
+	}
+
+	return lines, broken
+}
@@ -0,0 +1,456 @@
+package script
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/marcokaiser/touchportal-golang-sdk/client"
+	"go.starlark.net/lib/json"
+	"go.starlark.net/lib/math"
+	starlarktime "go.starlark.net/lib/time"
+	"go.starlark.net/starlark"
+)
+
+// script is a single loaded script, along with the handlers and timers it has added
+type script struct {
+	runtime *Runtime
+	name    string
+
+	// callMu allows only a single call into the script at once
+	callMu sync.Mutex
+
+	mu       sync.Mutex
+	loading  bool
+	stopped  bool
+	nextID   int
+	pending  []pendingStart
+	cleanups map[int]func()
+}
+
+// pendingStart is a handler or timer added whilst the script loads, only started once
+// it has loaded successfully
+type pendingStart struct {
+	id    int
+	start func(id int) func()
+}
+
+// predeclared returns the functions and modules available to the script
+func (s *script) predeclared() starlark.StringDict {
+	return starlark.StringDict{
+		"on_action":    starlark.NewBuiltin("on_action", s.onAction),
+		"on_connector": starlark.NewBuiltin("on_connector", s.onConnector),
+		"on_settings":  starlark.NewBuiltin("on_settings", s.onSettings),
+		"update_state": starlark.NewBuiltin("update_state", s.updateState),
+		"create_state": starlark.NewBuiltin("create_state", s.createState),
+		"remove_state": starlark.NewBuiltin("remove_state", s.removeState),
+		"state":        starlark.NewBuiltin("state", s.state),
+		"setting":      starlark.NewBuiltin("setting", s.setting),
+		"every":        starlark.NewBuiltin("every", s.every),
+		"after":        starlark.NewBuiltin("after", s.after),
+		"log":          starlark.NewBuiltin("log", s.log),
+		"json":         json.Module,
+		"math":         math.Module,
+		"time":         starlarktime.Module,
+	}
+}
+
+// activate starts the handlers and timers added whilst the script loaded
+func (s *script) activate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.loading = false
+	for _, p := range s.pending {
+		s.cleanups[p.id] = p.start(p.id)
+	}
+	s.pending = nil
+}
+
+// stop removes the handlers and stops the timers of the script, waiting for any call
+// into it to finish
+func (s *script) stop() {
+	s.mu.Lock()
+	s.stopped = true
+	cleanups := s.cleanups
+	s.cleanups = nil
+	s.mu.Unlock()
+
+	for _, cleanup := range cleanups {
+		cleanup()
+	}
+
+	s.callMu.Lock()
+	defer s.callMu.Unlock()
+}
+
+// add starts a handler or timer, or if the script is still loading does so once it has
+// loaded. The function start returns stops it again when the script is unloaded.
+func (s *script) add(start func(id int) func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stopped {
+		return
+	}
+
+	id := s.nextID
+	s.nextID++
+
+	if s.loading {
+		s.pending = append(s.pending, pendingStart{id: id, start: start})
+		return
+	}
+
+	s.cleanups[id] = start(id)
+}
+
+// remove forgets a timer that has fired
+func (s *script) remove(id int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.cleanups, id)
+}
+
+// addHandler adds a handler, which is only allowed whilst the script loads so that
+// reloading a script never leaves handlers from the old version behind
+func (s *script) addHandler(name string, register func() client.Unsubscribe) error {
+	s.mu.Lock()
+	loading := s.loading
+	s.mu.Unlock()
+
+	if !loading {
+		return fmt.Errorf("%s: handlers can only be added at the top level of a script", name)
+	}
+
+	s.add(func(int) func() {
+		return register()
+	})
+
+	return nil
+}
+
+// call calls fn with args, reporting any error it fails with
+func (s *script) call(fn starlark.Callable, args ...starlark.Value) {
+	s.callMu.Lock()
+	defer s.callMu.Unlock()
+
+	s.mu.Lock()
+	stopped := s.stopped
+	s.mu.Unlock()
+
+	if stopped {
+		return
+	}
+
+	thread, done := s.runtime.thread(s)
+	defer done()
+
+	if _, err := starlark.Call(thread, fn, args, nil); err != nil {
+		s.runtime.logger.Error("script failed", "pluginId", s.runtime.plugin.ID, "script", s.name, "error", describe(err))
+	}
+}
+
+// on_action(action_id, fn) calls fn(action_id, data) for each matching action
+func (s *script) onAction(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var (
+		id string
+		fn starlark.Callable
+	)
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "action_id", &id, "fn", &fn); err != nil {
+		return nil, err
+	}
+
+	if _, err := path.Match(id, ""); err != nil {
+		return nil, fmt.Errorf("%s: invalid action id %q: %w", b.Name(), id, err)
+	}
+
+	return starlark.None, s.addHandler(b.Name(), func() client.Unsubscribe {
+		return s.runtime.plugin.OnAction(func(event client.ActionMessage) {
+			data, err := event.Values()
+			if err != nil {
+				s.runtime.logger.Warn("unable to read action data", "pluginId", s.runtime.plugin.ID, "actionId", event.ActionID, "error", err)
+				return
+			}
+
+			s.call(fn, starlark.String(event.ActionID), toStarlark(data))
+		}, id)
+	})
+}
+
+// on_connector(connector_id, fn) calls fn(connector_id, value, data) for each matching
+// connector change
+func (s *script) onConnector(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var (
+		id string
+		fn starlark.Callable
+	)
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "connector_id", &id, "fn", &fn); err != nil {
+		return nil, err
+	}
+
+	if _, err := path.Match(id, ""); err != nil {
+		return nil, fmt.Errorf("%s: invalid connector id %q: %w", b.Name(), id, err)
+	}
+
+	return starlark.None, s.addHandler(b.Name(), func() client.Unsubscribe {
+		return s.runtime.plugin.OnConnectorChange(func(event client.ConnectorChangeMessage) {
+			data, err := event.Values()
+			if err != nil {
+				s.runtime.logger.Warn("unable to read connector data", "pluginId", s.runtime.plugin.ID, "connectorId", event.ConnectorID, "error", err)
+				return
+			}
+
+			s.call(fn, starlark.String(event.ConnectorID), starlark.MakeInt(event.Value), toStarlark(data))
+		}, id)
+	})
+}
+
+// on_settings(fn) calls fn(settings) each time TouchPortal sends the plugins settings
+func (s *script) onSettings(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var fn starlark.Callable
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "fn", &fn); err != nil {
+		return nil, err
+	}
+
+	return starlark.None, s.addHandler(b.Name(), func() client.Unsubscribe {
+		return s.runtime.plugin.OnSettings(func(event client.SettingsMessage) {
+			s.call(fn, toStarlark(event.Values))
+		})
+	})
+}
+
+// update_state(id, value) sends the value of a state, anything other than a string being
+// sent as str(value) would give
+func (s *script) updateState(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var (
+		id    string
+		value starlark.Value
+	)
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "id", &id, "value", &value); err != nil {
+		return nil, err
+	}
+
+	if err := s.runtime.plugin.UpdateState(id, toString(value)); err != nil {
+		return nil, fmt.Errorf("%s: %w", b.Name(), err)
+	}
+
+	return starlark.None, nil
+}
+
+// create_state(id, description, default="", group="") adds a state at runtime
+func (s *script) createState(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var id, description, defaultValue, group string
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "id", &id, "description", &description, "default?", &defaultValue, "group?", &group); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if group == "" {
+		err = s.runtime.plugin.CreateState(id, description, defaultValue)
+	} else {
+		err = s.runtime.plugin.CreateStateInGroup(id, description, defaultValue, group)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", b.Name(), err)
+	}
+
+	return starlark.None, nil
+}
+
+// remove_state(id) removes a state added at runtime
+func (s *script) removeState(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var id string
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "id", &id); err != nil {
+		return nil, err
+	}
+
+	if err := s.runtime.plugin.RemoveState(id); err != nil {
+		return nil, fmt.Errorf("%s: %w", b.Name(), err)
+	}
+
+	return starlark.None, nil
+}
+
+// state(id, default=None) returns the last value sent for a state
+func (s *script) state(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var (
+		id           string
+		defaultValue starlark.Value = starlark.None
+	)
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "id", &id, "default?", &defaultValue); err != nil {
+		return nil, err
+	}
+
+	value, ok := s.runtime.plugin.State(id)
+	if !ok {
+		return defaultValue, nil
+	}
+
+	return starlark.String(value), nil
+}
+
+// setting(name, default=None) returns the value of one of the plugins settings
+func (s *script) setting(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var (
+		name         string
+		defaultValue starlark.Value = starlark.None
+	)
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "name", &name, "default?", &defaultValue); err != nil {
+		return nil, err
+	}
+
+	value, ok := s.runtime.setting(name)
+	if !ok {
+		return defaultValue, nil
+	}
+
+	return toStarlark(value), nil
+}
+
+// every(interval, fn) calls fn() every interval, given in seconds or as a duration such
+// as "1m30s", until the script is unloaded
+func (s *script) every(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var (
+		interval starlark.Value
+		fn       starlark.Callable
+	)
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "interval", &interval, "fn", &fn); err != nil {
+		return nil, err
+	}
+
+	d, err := toDuration(interval)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", b.Name(), err)
+	}
+
+	s.add(func(int) func() {
+		job := s.runtime.plugin.Every(d, func(context.Context) {
+			s.call(fn)
+		})
+
+		return job.Stop
+	})
+
+	return starlark.None, nil
+}
+
+// after(delay, fn) calls fn() once after delay, given in seconds or as a duration such
+// as "500ms", unless the script is unloaded first
+func (s *script) after(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var (
+		delay starlark.Value
+		fn    starlark.Callable
+	)
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "delay", &delay, "fn", &fn); err != nil {
+		return nil, err
+	}
+
+	d, err := toDuration(delay)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", b.Name(), err)
+	}
+
+	s.add(func(id int) func() {
+		t := time.AfterFunc(d, func() {
+			s.remove(id)
+			s.call(fn)
+		})
+
+		return func() {
+			t.Stop()
+		}
+	})
+
+	return starlark.None, nil
+}
+
+// log(*args) logs its arguments, separated by spaces
+func (s *script) log(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if len(kwargs) > 0 {
+		return nil, fmt.Errorf("%s: unexpected keyword arguments", b.Name())
+	}
+
+	parts := make([]string, 0, len(args))
+	for _, arg := range args {
+		parts = append(parts, toString(arg))
+	}
+
+	s.runtime.logger.Info(strings.Join(parts, " "), "pluginId", s.runtime.plugin.ID, "script", s.name)
+
+	return starlark.None, nil
+}
+
+// toString returns a string as it is and anything else as str() would
+func toString(v starlark.Value) string {
+	if s, ok := starlark.AsString(v); ok {
+		return s
+	}
+
+	return v.String()
+}
+
+// toDuration reads a duration given in seconds or as a string such as "1m30s"
+func toDuration(v starlark.Value) (time.Duration, error) {
+	var d time.Duration
+	if s, ok := starlark.AsString(v); ok {
+		parsed, err := time.ParseDuration(s)
+		if err != nil {
+			return 0, err
+		}
+		d = parsed
+	} else if f, ok := starlark.AsFloat(v); ok {
+		d = time.Duration(f * float64(time.Second))
+	} else {
+		return 0, fmt.Errorf("duration should be a number of seconds or a string, got %s", v.Type())
+	}
+
+	if d <= 0 {
+		return 0, fmt.Errorf("duration %s must be positive", d)
+	}
+
+	return d, nil
+}
+
+// toStarlark converts the values of messages and settings into Starlark values
+func toStarlark(v interface{}) starlark.Value {
+	switch x := v.(type) {
+	case nil:
+		return starlark.None
+	case string:
+		return starlark.String(x)
+	case bool:
+		return starlark.Bool(x)
+	case int:
+		return starlark.MakeInt(x)
+	case float64:
+		return starlark.Float(x)
+	case map[string]string:
+		d := starlark.NewDict(len(x))
+		for k, v := range x {
+			_ = d.SetKey(starlark.String(k), starlark.String(v))
+		}
+
+		return d
+	case map[string]interface{}:
+		d := starlark.NewDict(len(x))
+		for k, v := range x {
+			_ = d.SetKey(starlark.String(k), toStarlark(v))
+		}
+
+		return d
+	case []interface{}:
+		l := make([]starlark.Value, 0, len(x))
+		for _, v := range x {
+			l = append(l, toStarlark(v))
+		}
+
+		return starlark.NewList(l)
+	default:
+		return starlark.String(fmt.Sprint(x))
+	}
+}
@@ -0,0 +1,367 @@
+// Package script runs plugin behaviour written in Starlark, a small Python like language,
+// so it can be changed without writing or rebuilding any Go.
+//
+// Scripts are the .star files in the plugins folder. Each is run once when loaded, when
+// it registers handlers and timers using the functions it is given:
+//
+//	def toggle(action_id, data):
+//	    on = state("gsdk_light", "off") == "off"
+//	    update_state("gsdk_light", "on" if on else "off")
+//
+//	on_action("gsdk_toggle", toggle)
+//	every("30s", lambda: update_state("gsdk_host", setting("Host", "unknown")))
+//
+// The full set of functions is on_action, on_connector, on_settings, update_state,
+// create_state, remove_state, state, setting, every, after and log, along with the json,
+// math and time modules.
+//
+// A script is reloaded when its file changes, its handlers and timers being replaced by
+// those of the new version. Should the new version fail to load the old one carries on.
+// Scripts have no access to files, the network or other programs, and each call into a
+// script is limited in how many steps it may take and how long it may run for.
+package script
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/marcokaiser/touchportal-golang-sdk/client"
+	"github.com/marcokaiser/touchportal-golang-sdk/plugin"
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+)
+
+const (
+	// DefaultMaxSteps is the number of steps a call into a script may take when no other
+	// limit is set using WithMaxSteps
+	DefaultMaxSteps = 1_000_000
+
+	// DefaultCallTimeout is how long a call into a script may run for when no other
+	// timeout is set using WithCallTimeout
+	DefaultCallTimeout = time.Second
+
+	// DefaultReloadInterval is how often the scripts folder is checked for changes when no
+	// other interval is set using WithReloadInterval
+	DefaultReloadInterval = time.Second
+)
+
+// Ext is the extension of the script files loaded from the folder
+const Ext = ".star"
+
+// fileOptions allows the loops and top level statements scripts are expected to use,
+// the step limit keeping them from running forever
+var fileOptions = &syntax.FileOptions{
+	Set:             true,
+	While:           true,
+	TopLevelControl: true,
+	GlobalReassign:  true,
+}
+
+// Option allows the configuration of a Runtime when calling New
+type Option func(r *Runtime)
+
+// WithDir sets the folder scripts are loaded from. It defaults to the directory of the
+// plugins executable, which is the folder the plugin is installed in.
+func WithDir(dir string) Option {
+	return func(r *Runtime) {
+		r.dir = dir
+	}
+}
+
+// WithLogger sets the Logger that script errors, and anything scripts log, are sent to
+func WithLogger(l client.Logger) Option {
+	return func(r *Runtime) {
+		r.logger = l
+	}
+}
+
+// WithMaxSteps sets the number of steps a call into a script may take before it is stopped
+func WithMaxSteps(n uint64) Option {
+	return func(r *Runtime) {
+		r.maxSteps = n
+	}
+}
+
+// WithCallTimeout sets how long a call into a script may run before it is stopped
+func WithCallTimeout(d time.Duration) Option {
+	return func(r *Runtime) {
+		r.callTimeout = d
+	}
+}
+
+// WithReloadInterval sets how often the folder is checked for changed scripts. A zero
+// interval turns off reloading.
+func WithReloadInterval(d time.Duration) Option {
+	return func(r *Runtime) {
+		r.reloadInterval = d
+	}
+}
+
+// Runtime loads and runs the scripts of a plugin
+type Runtime struct {
+	plugin         *plugin.Plugin
+	dir            string
+	logger         client.Logger
+	maxSteps       uint64
+	callTimeout    time.Duration
+	reloadInterval time.Duration
+
+	settingsMu sync.RWMutex
+	settings   map[string]interface{}
+	unsub      client.Unsubscribe
+
+	// mu is held whilst loading scripts, so only a single reload happens at once
+	mu       sync.Mutex
+	scripts  map[string]*script
+	versions map[string]version
+	watch    *plugin.Job
+}
+
+// version identifies the content of a script file, without reading it
+type version struct {
+	modTime time.Time
+	size    int64
+}
+
+// New creates a Runtime for the plugin. It should be created before calling Register so
+// the scripts are given the plugins settings.
+func New(p *plugin.Plugin, opts ...Option) *Runtime {
+	r := &Runtime{
+		plugin:         p,
+		logger:         client.DefaultLogger(),
+		maxSteps:       DefaultMaxSteps,
+		callTimeout:    DefaultCallTimeout,
+		reloadInterval: DefaultReloadInterval,
+		settings:       make(map[string]interface{}),
+		scripts:        make(map[string]*script),
+		versions:       make(map[string]version),
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	r.unsub = p.OnSettings(r.updateSettings)
+
+	return r
+}
+
+// Start loads every script in the folder and begins watching it for changes. Scripts that
+// fail to load are reported in the returned error, those that did load are left running.
+func (r *Runtime) Start() error {
+	if r.dir == "" {
+		dir, err := defaultDir()
+		if err != nil {
+			return err
+		}
+		r.dir = dir
+	}
+
+	err := r.reload()
+
+	if r.reloadInterval > 0 {
+		r.mu.Lock()
+		if r.watch == nil {
+			r.watch = r.plugin.Every(r.reloadInterval, func(ctx context.Context) {
+				_ = r.reload()
+			})
+		}
+		r.mu.Unlock()
+	}
+
+	return err
+}
+
+// Stop unloads every script, removing their handlers and stopping their timers. The
+// Runtime cannot be started again afterwards.
+func (r *Runtime) Stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.watch != nil {
+		r.watch.Stop()
+		r.watch = nil
+	}
+
+	for path, s := range r.scripts {
+		s.stop()
+		delete(r.scripts, path)
+	}
+	r.versions = make(map[string]version)
+
+	r.unsub()
+}
+
+// Scripts returns the names of the scripts currently loaded
+func (r *Runtime) Scripts() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	names := make([]string, 0, len(r.scripts))
+	for _, s := range r.scripts {
+		names = append(names, s.name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// reload loads scripts that are new or have changed since they were last loaded, and
+// unloads those whose files have gone.
+func (r *Runtime) reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries, err := os.ReadDir(r.dir)
+	if err != nil {
+		return fmt.Errorf("unable to read scripts folder: %w", err)
+	}
+
+	seen := make(map[string]bool, len(entries))
+
+	var errs []error
+	for _, e := range entries {
+		if e.IsDir() || !strings.EqualFold(filepath.Ext(e.Name()), Ext) {
+			continue
+		}
+
+		path := filepath.Join(r.dir, e.Name())
+		seen[path] = true
+
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+
+		v := version{modTime: info.ModTime(), size: info.Size()}
+		if known, ok := r.versions[path]; ok && known == v {
+			continue
+		}
+
+		// a failed load is remembered too, so it is only retried once the file changes
+		r.versions[path] = v
+
+		if err := r.load(path); err != nil {
+			r.logger.Error("unable to load script", "pluginId", r.plugin.ID, "script", e.Name(), "error", err)
+			errs = append(errs, fmt.Errorf("%s: %w", e.Name(), err))
+		}
+	}
+
+	for path, s := range r.scripts {
+		if !seen[path] {
+			r.logger.Info("unloading removed script", "pluginId", r.plugin.ID, "script", s.name)
+
+			s.stop()
+			delete(r.scripts, path)
+		}
+	}
+
+	for path := range r.versions {
+		if !seen[path] {
+			delete(r.versions, path)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// load runs the script at path, replacing any version of it already loaded once it has
+// run successfully.
+func (r *Runtime) load(path string) error {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	s := &script{
+		runtime:  r,
+		name:     filepath.Base(path),
+		loading:  true,
+		cleanups: make(map[int]func()),
+	}
+
+	thread, done := r.thread(s)
+	_, err = starlark.ExecFileOptions(fileOptions, thread, path, src, s.predeclared())
+	done()
+	if err != nil {
+		return describe(err)
+	}
+
+	if old, ok := r.scripts[path]; ok {
+		old.stop()
+	}
+	r.scripts[path] = s
+
+	s.activate()
+
+	r.logger.Info("loaded script", "pluginId", r.plugin.ID, "script", s.name)
+
+	return nil
+}
+
+// thread returns a thread for a single call into the script, limited in the steps it
+// may take and how long it may run for. The returned function must be called once the
+// call has finished.
+func (r *Runtime) thread(s *script) (*starlark.Thread, func()) {
+	thread := &starlark.Thread{
+		Name: s.name,
+		Print: func(_ *starlark.Thread, msg string) {
+			r.logger.Info(msg, "pluginId", r.plugin.ID, "script", s.name)
+		},
+		Load: func(_ *starlark.Thread, module string) (starlark.StringDict, error) {
+			return nil, fmt.Errorf("unable to load %q, scripts cannot load other files", module)
+		},
+	}
+	thread.SetMaxExecutionSteps(r.maxSteps)
+
+	timer := time.AfterFunc(r.callTimeout, func() {
+		thread.Cancel(fmt.Sprintf("took longer than %s", r.callTimeout))
+	})
+
+	return thread, func() {
+		timer.Stop()
+	}
+}
+
+func (r *Runtime) updateSettings(event client.SettingsMessage) {
+	r.settingsMu.Lock()
+	defer r.settingsMu.Unlock()
+
+	r.settings = event.Values
+}
+
+func (r *Runtime) setting(name string) (interface{}, bool) {
+	r.settingsMu.RLock()
+	defer r.settingsMu.RUnlock()
+
+	v, ok := r.settings[name]
+
+	return v, ok
+}
+
+// defaultDir is the folder the plugin is installed in
+func defaultDir() (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("unable to find the scripts folder: %w", err)
+	}
+
+	return filepath.Dir(exe), nil
+}
+
+// describe includes the script backtrace in the error of a failed call
+func describe(err error) error {
+	var evalErr *starlark.EvalError
+	if errors.As(err, &evalErr) {
+		return errors.New(evalErr.Backtrace())
+	}
+
+	return err
+}
@@ -0,0 +1,197 @@
+package script
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/marcokaiser/touchportal-golang-sdk/client"
+	"github.com/marcokaiser/touchportal-golang-sdk/plugin"
+	"github.com/marcokaiser/touchportal-golang-sdk/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeScript(t *testing.T, dir, name, src string) {
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(src), 0o644))
+}
+
+func newRuntime(t *testing.T, p *plugin.Plugin, dir string, opts ...Option) *Runtime {
+	r := New(p, append([]Option{WithDir(dir), WithReloadInterval(0)}, opts...)...)
+	t.Cleanup(r.Stop)
+
+	return r
+}
+
+func TestRuntime_handlers(t *testing.T) {
+	t.Parallel()
+
+	tp := plugintest.New(t, "test")
+	p, c := tp.Plugin, tp.Client
+	dir := t.TempDir()
+
+	writeScript(t, dir, "main.star", `
+def greet(action_id, data):
+    update_state("greeting", "hello " + data["name"])
+
+def volume(connector_id, value, data):
+    update_state("volume", value * 2)
+
+def settings(values):
+    update_state("host", values["Host"])
+
+on_action("gsdk_greet", greet)
+on_connector("gsdk_*", volume)
+on_settings(settings)
+update_state("loaded", state("loaded", "no") + "!")
+`)
+
+	r := newRuntime(t, p, dir)
+	assert.NoError(t, r.Start())
+	assert.Equal(t, []string{"main.star"}, r.Scripts())
+
+	value, _ := p.State("loaded")
+	assert.Equal(t, "no!", value)
+
+	assert.NoError(t, p.DispatchAction("gsdk_greet", map[string]string{"name": "world"}))
+	value, _ = p.State("greeting")
+	assert.Equal(t, "hello world", value)
+
+	c.Dispatch(client.MessageTypeConnectorChange, client.ConnectorChangeMessage{
+		Message:     client.Message{Type: client.MessageTypeConnectorChange},
+		PluginID:    "test",
+		ConnectorID: "gsdk_volume",
+		Value:       21,
+	})
+	value, _ = p.State("volume")
+	assert.Equal(t, "42", value)
+
+	c.Dispatch(client.MessageTypeSettings, client.SettingsMessage{
+		Message:   client.Message{Type: client.MessageTypeSettings},
+		RawValues: json.RawMessage(`[{"Host":"localhost"}]`),
+	})
+	value, _ = p.State("host")
+	assert.Equal(t, "localhost", value)
+}
+
+func TestRuntime_reload(t *testing.T) {
+	t.Parallel()
+
+	p := plugintest.New(t, "test").Plugin
+	dir := t.TempDir()
+
+	writeScript(t, dir, "main.star", `on_action("gsdk_version", lambda a, d: update_state("version", "1"))`)
+
+	r := newRuntime(t, p, dir)
+	assert.NoError(t, r.Start())
+
+	// a broken version is reported, the old one carrying on
+	writeScript(t, dir, "main.star", `on_action("gsdk_version", lambda a, d: update_state("version", "2")`)
+	assert.Error(t, r.reload())
+
+	assert.NoError(t, p.DispatchAction("gsdk_version", nil))
+	value, _ := p.State("version")
+	assert.Equal(t, "1", value)
+
+	writeScript(t, dir, "main.star", `on_action("gsdk_version", lambda a, d: update_state("version", "3"))`)
+	assert.NoError(t, r.reload())
+
+	assert.NoError(t, p.DispatchAction("gsdk_version", nil))
+	value, _ = p.State("version")
+	assert.Equal(t, "3", value)
+
+	// removing the script removes its handlers
+	assert.NoError(t, os.Remove(filepath.Join(dir, "main.star")))
+	assert.NoError(t, r.reload())
+	assert.Empty(t, r.Scripts())
+	assert.ErrorIs(t, p.DispatchAction("gsdk_version", nil), plugin.ErrNoRoute)
+}
+
+func TestRuntime_timers(t *testing.T) {
+	t.Parallel()
+
+	p := plugintest.New(t, "test").Plugin
+	dir := t.TempDir()
+
+	writeScript(t, dir, "timers.star", `
+after(0.01, lambda: update_state("after", "fired"))
+every("10ms", lambda: update_state("every", "ticked"))
+`)
+
+	r := newRuntime(t, p, dir)
+	assert.NoError(t, r.Start())
+
+	assert.Eventually(t, func() bool {
+		after, _ := p.State("after")
+		every, _ := p.State("every")
+
+		return after == "fired" && every == "ticked"
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestRuntime_limits(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		src  string
+		opts []Option
+	}{
+		{name: "too many steps", src: "while True:\n    pass", opts: []Option{WithMaxSteps(1000), WithCallTimeout(time.Minute)}},
+		{name: "too long", src: "while True:\n    pass", opts: []Option{WithMaxSteps(0), WithCallTimeout(10 * time.Millisecond)}},
+		{name: "loading files", src: `load("other.star", "x")`},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := plugintest.New(t, "test").Plugin
+			dir := t.TempDir()
+			writeScript(t, dir, "limited.star", tt.src)
+
+			r := newRuntime(t, p, dir, tt.opts...)
+			assert.Error(t, r.Start())
+			assert.Empty(t, r.Scripts())
+		})
+	}
+}
+
+func TestRuntime_lateHandler(t *testing.T) {
+	t.Parallel()
+
+	p := plugintest.New(t, "test").Plugin
+	dir := t.TempDir()
+
+	writeScript(t, dir, "late.star", `
+def late():
+    on_action("gsdk_late", lambda a, d: None)
+    update_state("late", "added")
+
+after(0.01, late)
+`)
+
+	r := newRuntime(t, p, dir)
+	assert.NoError(t, r.Start())
+
+	// the handler is refused, failing the call before the state is updated
+	time.Sleep(50 * time.Millisecond)
+	assert.ErrorIs(t, p.DispatchAction("gsdk_late", nil), plugin.ErrNoRoute)
+
+	_, ok := p.State("late")
+	assert.False(t, ok)
+}
+
+func TestRuntime_defaultDir(t *testing.T) {
+	t.Parallel()
+
+	exe, err := os.Executable()
+	assert.NoError(t, err)
+
+	// scripts are loaded from beside the plugins executable, whatever its install folder is named
+	dir, err := defaultDir()
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Dir(exe), dir)
+}